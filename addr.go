@@ -0,0 +1,66 @@
+package xxl
+
+import (
+	"fmt"
+	"net"
+)
+
+// 按网卡名称、网段选择本机IP,ipv6为true时优先IPv6
+func selectIP(ifaceName, cidr string, ipv6 bool) (string, error) {
+	var network *net.IPNet
+	if cidr != "" {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return "", err
+		}
+		network = n
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", err
+	}
+	var v4, v6 net.IP
+	for _, iface := range ifaces {
+		if ifaceName != "" && iface.Name != ifaceName {
+			continue
+		}
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		if ifaceName == "" && iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			ipNet, ok := a.(*net.IPNet)
+			if !ok || ipNet.IP.IsLinkLocalUnicast() {
+				continue
+			}
+			ip := ipNet.IP
+			if network != nil && !network.Contains(ip) {
+				continue
+			}
+			if ip.To4() != nil {
+				if v4 == nil {
+					v4 = ip
+				}
+			} else if v6 == nil {
+				v6 = ip
+			}
+		}
+	}
+	first, second := v4, v6
+	if ipv6 {
+		first, second = v6, v4
+	}
+	if first != nil {
+		return first.String(), nil
+	}
+	if second != nil {
+		return second.String(), nil
+	}
+	return "", fmt.Errorf("no ip found, interface=%q cidr=%q", ifaceName, cidr)
+}
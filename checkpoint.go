@@ -0,0 +1,161 @@
+package xxl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+/**
+断点续跑:任务中通过CommitCheckpoint保存处理位置,被终止、覆盖或执行器重启后,
+同一任务(按JobID和分片区分)下次调度时通过LoadCheckpoint恢复,处理完成后调用ClearCheckpoint清除。
+断点只在显式提交时保存,不会自动清除。设置LogDir时默认保存在 LogDir/checkpoint 目录
+*/
+
+// CheckpointKey 断点标识
+type CheckpointKey struct {
+	JobID      int64 `json:"jobId"`      //任务ID
+	ShardIndex int64 `json:"shardIndex"` //分片参数：当前分片
+	ShardTotal int64 `json:"shardTotal"` //分片参数：总分片
+}
+
+// CheckpointStore 断点存储
+type CheckpointStore interface {
+	// Load 读取断点,不存在时返回nil
+	Load(key CheckpointKey) ([]byte, error)
+	// Save 保存断点
+	Save(key CheckpointKey, data []byte) error
+	// Clear 清除断点,不存在时不返回错误
+	Clear(key CheckpointKey) error
+}
+
+// ErrNoCheckpointStore 未设置断点存储
+var ErrNoCheckpointStore = errors.New("xxl: checkpoint store not configured")
+
+// FileCheckpointStore 文件断点存储,每个断点一个文件 dir/jobId_shardIndex_shardTotal.json
+func FileCheckpointStore(dir string) CheckpointStore {
+	return &fileCheckpointStore{dir: dir}
+}
+
+type fileCheckpointStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+func (f *fileCheckpointStore) path(key CheckpointKey) string {
+	return filepath.Join(f.dir, fmt.Sprintf("%d_%d_%d.json", key.JobID, key.ShardIndex, key.ShardTotal))
+}
+
+func (f *fileCheckpointStore) Load(key CheckpointKey) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, err := ioutil.ReadFile(f.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (f *fileCheckpointStore) Save(key CheckpointKey, data []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return err
+	}
+	path := f.path(key)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (f *fileCheckpointStore) Clear(key CheckpointKey) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := os.Remove(f.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// 断点存储,未设置时使用LogDir下的文件存储
+func newCheckpointStore(opts Options) CheckpointStore {
+	if opts.checkpoint != nil {
+		return opts.checkpoint
+	}
+	if opts.LogDir != "" {
+		return FileCheckpointStore(filepath.Join(opts.LogDir, "checkpoint"))
+	}
+	return nil
+}
+
+// 本次调度的断点标识
+func (t *Task) checkpointKey() CheckpointKey {
+	return CheckpointKey{JobID: t.Param.JobID, ShardIndex: t.Param.BroadcastIndex, ShardTotal: t.Param.BroadcastTotal}
+}
+
+// 任务上下文中的断点存储
+func checkpointFrom(ctx context.Context) (*Task, error) {
+	t := taskFrom(ctx)
+	if t == nil {
+		return nil, ErrNotInTask
+	}
+	if t.ckpt == nil {
+		return nil, ErrNoCheckpointStore
+	}
+	return t, nil
+}
+
+// LoadCheckpoint 读取上次提交的断点并JSON解析到v,没有断点时返回false
+func LoadCheckpoint(ctx context.Context, v interface{}) (bool, error) {
+	t, err := checkpointFrom(ctx)
+	if err != nil {
+		return false, err
+	}
+	data, err := t.ckpt.Load(t.checkpointKey())
+	if err != nil || data == nil {
+		return false, err
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return false, err
+	}
+	t.jlog.Write("恢复断点:%s", data)
+	return true, nil
+}
+
+// CommitCheckpoint 提交断点,v按JSON保存,覆盖上次提交
+func CommitCheckpoint(ctx context.Context, v interface{}) error {
+	t, err := checkpointFrom(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err = t.ckpt.Save(t.checkpointKey(), data); err != nil {
+		t.log.Error("断点保存失败", F(FieldError, err))
+		return err
+	}
+	t.jlog.Write("提交断点:%s", data)
+	return nil
+}
+
+// ClearCheckpoint 清除断点,任务处理完成后调用,下次调度从头开始
+func ClearCheckpoint(ctx context.Context) error {
+	t, err := checkpointFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err = t.ckpt.Clear(t.checkpointKey()); err != nil {
+		return err
+	}
+	t.jlog.Write("清除断点")
+	return nil
+}
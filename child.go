@@ -0,0 +1,95 @@
+package xxl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+/**
+子任务:任务中通过TriggerChild添加,本次调度执行成功并回调后依次请求调度中心触发,
+子任务的调度日志ID记录到本次调度的任务日志中。官方调度中心没有触发接口,需自行扩展,
+接口路径通过ChildTriggerPath设置,未设置时不支持子任务
+*/
+
+// 子任务
+type childJob struct {
+	JobID  int64  `json:"jobId"`
+	Params string `json:"params"`
+}
+
+var (
+	// ErrNotInTask 不在任务上下文中
+	ErrNotInTask = errors.New("xxl: context is not a task context")
+	// ErrChildTriggerDisabled 未设置ChildTriggerPath
+	ErrChildTriggerDisabled = errors.New("xxl: child trigger path not configured")
+)
+
+// TriggerChild 添加子任务,本次调度执行成功后由调度中心触发,params为空时使用子任务配置的参数;
+// 未设置ChildTriggerPath时返回ErrChildTriggerDisabled
+func TriggerChild(ctx context.Context, jobID int64, params string) error {
+	t := taskFrom(ctx)
+	if t == nil {
+		return ErrNotInTask
+	}
+	if t.onChild == nil {
+		return ErrChildTriggerDisabled
+	}
+	t.mu.Lock()
+	t.children = append(t.children, childJob{JobID: jobID, Params: params})
+	t.mu.Unlock()
+	t.jlog.Write("添加子任务 jobId:%d params:%s", jobID, params)
+	return nil
+}
+
+// 触发本次调度添加的子任务
+func (e *executor) triggerChildren(t *Task) {
+	t.mu.Lock()
+	children := t.children
+	t.children = nil
+	t.mu.Unlock()
+	for _, c := range children {
+		logID, err := e.triggerChild(t.Param, c)
+		if err != nil {
+			t.log.Error("子任务触发失败", F("child_job_id", c.JobID), F(FieldError, err))
+			t.jlog.Write("子任务触发失败 jobId:%d err:%v", c.JobID, err)
+			continue
+		}
+		t.log.Info("子任务触发成功", F("child_job_id", c.JobID), F("child_log_id", logID))
+		t.jlog.Write("子任务触发成功 jobId:%d logId:%d", c.JobID, logID)
+	}
+}
+
+// 请求调度中心触发子任务,依次尝试各调度中心,返回子任务调度日志ID
+func (e *executor) triggerChild(parent *RunReq, c childJob) (logID int64, err error) {
+	if len(e.admins) == 0 {
+		return 0, errors.New("no admin address")
+	}
+	param, _ := json.Marshal(&childTriggerReq{
+		JobID:         c.JobID,
+		ExecutorParam: c.Params,
+		ParentJobID:   parent.JobID,
+		ParentLogID:   parent.LogID,
+	})
+	for _, addr := range e.admins {
+		var body []byte
+		body, err = e.adminCall(context.Background(), addr, e.opts.ChildTriggerPath, param)
+		if err != nil {
+			continue
+		}
+		return childLogID(body), nil
+	}
+	return 0, err
+}
+
+// 解析响应中的子任务调度日志ID,调度中心未返回时为0
+func childLogID(body []byte) int64 {
+	r := &childTriggerRes{}
+	if json.Unmarshal(body, r) != nil {
+		return 0
+	}
+	id, _ := strconv.ParseInt(strings.Trim(string(r.Content), `"`), 10, 64)
+	return id
+}
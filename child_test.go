@@ -0,0 +1,86 @@
+package xxl
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 子任务在回调之后触发
+func TestTriggerChild(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	child := make(chan childTriggerReq, 1)
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.URL.Path == "/api/callback" || r.URL.Path == "/api/child" {
+			mu.Lock()
+			paths = append(paths, r.URL.Path)
+			mu.Unlock()
+		}
+		if r.URL.Path != "/api/child" {
+			w.Write([]byte(`{"code":200}`))
+			return
+		}
+		req := childTriggerReq{}
+		json.Unmarshal(body, &req)
+		child <- req
+		w.Write([]byte(`{"code":200,"content":"99"}`))
+	}))
+	defer admin.Close()
+
+	e := newExecutor()
+	e.Init(ServerAddr(admin.URL), ChildTriggerPath("/api/child"))
+	defer e.Stop()
+	e.RegTask("task.parent", func(cxt context.Context, param *RunReq) string {
+		if err := TriggerChild(cxt, 7, "a=1"); err != nil {
+			t.Errorf("TriggerChild() error: %v", err)
+		}
+		return ""
+	})
+	if _, ok := e.trigger(&RunReq{JobID: 1, LogID: 10, ExecutorHandler: "task.parent"}, false); !ok {
+		t.Fatal("trigger rejected")
+	}
+	select {
+	case req := <-child:
+		want := childTriggerReq{JobID: 7, ExecutorParam: "a=1", ParentJobID: 1, ParentLogID: 10}
+		if req != want {
+			t.Fatalf("child request = %+v, want %+v", req, want)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("child not triggered")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(paths) != 2 || paths[0] != "/api/callback" || paths[1] != "/api/child" {
+		t.Fatalf("admin calls = %v, want callback before child trigger", paths)
+	}
+}
+
+// 未设置ChildTriggerPath时不支持子任务
+func TestTriggerChildDisabled(t *testing.T) {
+	e := newExecutor()
+	e.Init(Standalone())
+	errs := make(chan error, 1)
+	e.RegTask("task.parent", func(cxt context.Context, param *RunReq) string {
+		errs <- TriggerChild(cxt, 7, "")
+		return ""
+	})
+	e.trigger(&RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.parent"}, true)
+	select {
+	case err := <-errs:
+		if err != ErrChildTriggerDisabled {
+			t.Fatalf("TriggerChild() = %v, want ErrChildTriggerDisabled", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("task did not run")
+	}
+	if err := TriggerChild(context.Background(), 7, ""); err != ErrNotInTask {
+		t.Fatalf("TriggerChild() outside task = %v, want ErrNotInTask", err)
+	}
+}
@@ -0,0 +1,388 @@
+package xxl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+cron表达式,支持:
+  Quartz格式(与调度中心一致) 秒 分 时 日 月 周 [年],周取值1-7(1为周日)
+  标准格式                   分 时 日 月 周,周取值0-7(0、7为周日)
+  每个字段支持 * ? , - /,月和周支持英文缩写(JAN、MON),年字段仅支持*
+  日字段支持 L(月末) LW(月末最后一个工作日) 15W(离15日最近的工作日,不跨月)
+  周字段支持 6L(当月最后一个周五) 6#3(当月第三个周五),数值按各格式的周取值
+  @every 1m30s、@yearly、@monthly、@weekly、@daily、@hourly
+夏令时:跳过的时间不执行;重复的时间,时字段为*时按实际时间执行两次,否则只执行第一次
+*/
+
+// schedule 调度时间计算
+type schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// 按固定间隔调度
+type everySchedule time.Duration
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s)).Truncate(time.Second)
+}
+
+// cron调度,各字段用位图表示
+type cronSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+	domStar, dowStar                      bool
+	lastDay, lastWeekday                  bool     //L、LW
+	weekday                               uint64   //nW,离n日最近的工作日
+	lastDow                               uint64   //nL,当月最后一个周n
+	nthDow                                []nthDow //n#k,当月第k个周n
+}
+
+// 当月第nth个周weekday
+type nthDow struct {
+	weekday, nth int
+}
+
+type cronField struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondField = cronField{min: 0, max: 59}
+	minuteField = cronField{min: 0, max: 59}
+	hourField   = cronField{min: 0, max: 23}
+	domField    = cronField{min: 1, max: 31}
+	monthField  = cronField{min: 1, max: 12, names: map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}}
+	dowField = cronField{min: 0, max: 7, names: map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}}
+	quartzDowField = cronField{min: 1, max: 7, names: map[string]int{
+		"SUN": 1, "MON": 2, "TUE": 3, "WED": 4, "THU": 5, "FRI": 6, "SAT": 7,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 ?",
+	"@annually": "0 0 0 1 1 ?",
+	"@monthly":  "0 0 0 1 * ?",
+	"@weekly":   "0 0 0 ? * 1",
+	"@daily":    "0 0 0 * * ?",
+	"@midnight": "0 0 0 * * ?",
+	"@hourly":   "0 0 * * * ?",
+}
+
+// 解析cron表达式
+func parseCron(spec string) (schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(spec[len("@every "):]))
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: %v", spec, err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("cron %q: interval must be at least 1s", spec)
+		}
+		return everySchedule(d), nil
+	}
+	if d, ok := descriptors[spec]; ok {
+		spec = d
+	}
+	fields := strings.Fields(spec)
+	quartz := true
+	switch len(fields) {
+	case 5:
+		quartz = false
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	case 7:
+		if fields[6] != "*" {
+			return nil, fmt.Errorf("cron %q: year field only supports *", spec)
+		}
+		fields = fields[:6]
+	default:
+		return nil, fmt.Errorf("cron %q: expected 5, 6 or 7 fields", spec)
+	}
+	s := &cronSchedule{}
+	var err error
+	parse := func(expr string, f cronField) uint64 {
+		if err != nil || expr == "" {
+			return 0
+		}
+		var bits uint64
+		bits, err = parseCronField(expr, f)
+		if err != nil {
+			err = fmt.Errorf("cron %q: %v", spec, err)
+		}
+		return bits
+	}
+	s.second = parse(fields[0], secondField)
+	s.minute = parse(fields[1], minuteField)
+	s.hour = parse(fields[2], hourField)
+	dom, domErr := s.parseDom(fields[3])
+	s.dom = parse(dom, domField)
+	s.month = parse(fields[4], monthField)
+	dowF := dowField
+	if quartz {
+		dowF = quartzDowField
+	}
+	dow, dowErr := s.parseDow(fields[5], dowF, quartz)
+	if quartz {
+		//Quartz周字段 1-7 转为 0-6
+		s.dow = parse(dow, quartzDowField) >> 1
+	} else {
+		s.dow = parse(dow, dowField)
+		if s.dow&(1<<7) != 0 {
+			s.dow |= 1
+		}
+	}
+	for _, e := range []error{domErr, dowErr} {
+		if err == nil && e != nil {
+			err = fmt.Errorf("cron %q: %v", spec, e)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.domStar = isStar(fields[3])
+	s.dowStar = isStar(fields[5])
+	return s, nil
+}
+
+func isStar(expr string) bool {
+	return expr == "*" || expr == "?"
+}
+
+// 解析日字段中的L、LW、nW,返回其余部分
+func (s *cronSchedule) parseDom(expr string) (string, error) {
+	var rest []string
+	for _, part := range strings.Split(expr, ",") {
+		switch {
+		case part == "L":
+			s.lastDay = true
+		case part == "LW":
+			s.lastWeekday = true
+		case strings.HasSuffix(part, "W"):
+			v, err := domField.value(part[:len(part)-1])
+			if err != nil {
+				return "", err
+			}
+			s.weekday |= 1 << uint(v)
+		default:
+			rest = append(rest, part)
+		}
+	}
+	return strings.Join(rest, ","), nil
+}
+
+// 解析周字段中的nL、n#k,返回其余部分
+func (s *cronSchedule) parseDow(expr string, f cronField, quartz bool) (string, error) {
+	weekday := func(v string) (int, error) {
+		n, err := f.value(v)
+		if quartz {
+			return n - 1, err
+		}
+		return n % 7, err
+	}
+	var rest []string
+	for _, part := range strings.Split(expr, ",") {
+		if i := strings.Index(part, "#"); i >= 0 {
+			wd, err := weekday(part[:i])
+			if err != nil {
+				return "", err
+			}
+			nth, err := strconv.Atoi(part[i+1:])
+			if err != nil || nth < 1 || nth > 5 {
+				return "", fmt.Errorf("invalid value %q", part)
+			}
+			s.nthDow = append(s.nthDow, nthDow{weekday: wd, nth: nth})
+		} else if len(part) > 1 && strings.HasSuffix(part, "L") {
+			wd, err := weekday(part[:len(part)-1])
+			if err != nil {
+				return "", err
+			}
+			s.lastDow |= 1 << uint(wd)
+		} else {
+			rest = append(rest, part)
+		}
+	}
+	return strings.Join(rest, ","), nil
+}
+
+// 解析单个字段为位图
+func parseCronField(expr string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		start, end, step := f.min, f.max, 1
+		rangeExpr := part
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+			step = n
+			rangeExpr = part[:i]
+		}
+		switch {
+		case isStar(rangeExpr):
+		case strings.Contains(rangeExpr, "-"):
+			i := strings.Index(rangeExpr, "-")
+			var err error
+			if start, err = f.value(rangeExpr[:i]); err != nil {
+				return 0, err
+			}
+			if end, err = f.value(rangeExpr[i+1:]); err != nil {
+				return 0, err
+			}
+		default:
+			v, err := f.value(rangeExpr)
+			if err != nil {
+				return 0, err
+			}
+			start = v
+			if step == 1 {
+				end = v
+			}
+		}
+		if start > end {
+			return 0, fmt.Errorf("invalid range %q", part)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToUpper(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d,%d]", v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next 下一次调度时间,5年内无匹配时返回零值
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Second).Add(time.Second)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = forward(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location()))
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = forward(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location()))
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			//按实际时间前进,夏令时跳过的小时不会回退
+			t = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+		if s.second&(1<<uint(t.Second())) == 0 {
+			t = t.Add(time.Second)
+			continue
+		}
+		if s.hour != hourStar && s.repeated(t) {
+			t = t.Add(time.Second)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+var hourStar uint64 = 1<<24 - 1
+
+// 夏令时结束时重复的时间(第二次出现)
+func (s *cronSchedule) repeated(t time.Time) bool {
+	first := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	return !first.Equal(t)
+}
+
+// 跳到次日或次月零点,零点被夏令时跳过时time.Date会回退到之前,此时按实际时间补齐
+func forward(t, next time.Time) time.Time {
+	for !next.After(t) {
+		next = next.Add(time.Hour)
+	}
+	return next
+}
+
+// 日和周都有限定时满足其一即可,否则都需满足
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0 || s.domSpecial(t)
+	dow := s.dow&(1<<uint(t.Weekday())) != 0 || s.dowSpecial(t)
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// L、LW、nW
+func (s *cronSchedule) domSpecial(t time.Time) bool {
+	day, last := t.Day(), daysIn(t)
+	if s.lastDay && day == last {
+		return true
+	}
+	if s.lastWeekday && day == nearestWeekday(t, last, last) {
+		return true
+	}
+	for n := 1; s.weekday != 0 && n <= last; n++ {
+		if s.weekday&(1<<uint(n)) != 0 && day == nearestWeekday(t, n, last) {
+			return true
+		}
+	}
+	return false
+}
+
+// nL、n#k
+func (s *cronSchedule) dowSpecial(t time.Time) bool {
+	wd, day := int(t.Weekday()), t.Day()
+	if s.lastDow&(1<<uint(wd)) != 0 && day+7 > daysIn(t) {
+		return true
+	}
+	for _, d := range s.nthDow {
+		if d.weekday == wd && (day-1)/7+1 == d.nth {
+			return true
+		}
+	}
+	return false
+}
+
+// 当月天数
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 12, 0, 0, 0, time.UTC).Day()
+}
+
+// 当月离n日最近的工作日,不跨月
+func nearestWeekday(t time.Time, n, last int) int {
+	switch time.Weekday((int(t.Weekday()) + n - t.Day() + 35) % 7) {
+	case time.Saturday:
+		if n == 1 {
+			return 3
+		}
+		return n - 1
+	case time.Sunday:
+		if n == last {
+			return n - 2
+		}
+		return n + 1
+	}
+	return n
+}
@@ -0,0 +1,120 @@
+package xxl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	for _, spec := range []string{
+		"* * * *",
+		"0 0 0 * * ? 2024",
+		"@every 500ms",
+		"@every x",
+		"0 0 24 * * ?",
+		"0 */0 * * * ?",
+		"0 0 0 5-1 * ?",
+		"0 0 0 32W * ?",
+		"0 0 0 ? * 8#1",
+		"0 0 0 ? * 6#6",
+		"0 0 0 ? * 6#x",
+		"0 0 0 ? * 9L",
+		"0 0 * * 8",
+	} {
+		if _, err := parseCron(spec); err == nil {
+			t.Errorf("parseCron(%q) expected error", spec)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	utc := func(y int, m time.Month, d, h, min, s int) time.Time {
+		return time.Date(y, m, d, h, min, s, 0, time.UTC)
+	}
+	cases := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"*/15 * * * * ?", utc(2024, 1, 1, 0, 0, 7), utc(2024, 1, 1, 0, 0, 15)},
+		{"0 30 9 * * ?", utc(2024, 1, 1, 9, 30, 0), utc(2024, 1, 2, 9, 30, 0)},
+		{"0 0 0 29 2 ?", utc(2024, 3, 1, 0, 0, 0), utc(2028, 2, 29, 0, 0, 0)},
+		{"0 0 0 30 2 ?", utc(2024, 1, 1, 0, 0, 0), time.Time{}},
+		{"@weekly", utc(2024, 1, 1, 0, 0, 0), utc(2024, 1, 7, 0, 0, 0)},
+		{"@every 90s", time.Date(2024, 1, 1, 0, 0, 0, 5e8, time.UTC), utc(2024, 1, 1, 0, 1, 30)},
+		{"0 0 * * 7", utc(2024, 1, 1, 0, 0, 0), utc(2024, 1, 7, 0, 0, 0)},
+		{"0 0 0 ? * SUN", utc(2024, 1, 1, 0, 0, 0), utc(2024, 1, 7, 0, 0, 0)},
+		// 日和周都有限定时满足其一即可
+		{"0 0 0 15 * 2", utc(2024, 1, 31, 23, 59, 58), utc(2024, 2, 5, 0, 0, 0)},
+		{"0 0 15 * 1", utc(2024, 1, 31, 23, 59, 58), utc(2024, 2, 5, 0, 0, 0)},
+		{"0 0 0 15 * ?", utc(2024, 1, 31, 23, 59, 58), utc(2024, 2, 15, 0, 0, 0)},
+		{"0 0 0 ? * MON", utc(2024, 1, 31, 23, 59, 58), utc(2024, 2, 5, 0, 0, 0)},
+		// L、W、#
+		{"0 0 1 L * ?", utc(2024, 2, 1, 0, 0, 0), utc(2024, 2, 29, 1, 0, 0)},
+		{"0 0 1 L * ?", utc(2024, 2, 29, 1, 0, 0), utc(2024, 3, 31, 1, 0, 0)},
+		{"0 0 0 LW * ?", utc(2024, 3, 1, 0, 0, 0), utc(2024, 3, 29, 0, 0, 0)},
+		{"0 0 0 1W * ?", utc(2024, 5, 31, 12, 0, 0), utc(2024, 6, 3, 0, 0, 0)},
+		{"0 0 0 15W * ?", utc(2024, 9, 1, 0, 0, 0), utc(2024, 9, 16, 0, 0, 0)},
+		{"0 0 0 31W * ?", utc(2024, 4, 1, 0, 0, 0), utc(2024, 5, 31, 0, 0, 0)},
+		{"0 15 10 ? * 6#3", utc(2024, 1, 1, 0, 0, 0), utc(2024, 1, 19, 10, 15, 0)},
+		{"0 0 0 ? * 6L", utc(2024, 1, 1, 0, 0, 0), utc(2024, 1, 26, 0, 0, 0)},
+		{"0 9 * * 5#1", utc(2024, 1, 1, 0, 0, 0), utc(2024, 1, 5, 9, 0, 0)},
+	}
+	for _, c := range cases {
+		s, err := parseCron(c.spec)
+		if err != nil {
+			t.Fatalf("parseCron(%q) error: %v", c.spec, err)
+		}
+		if got := s.Next(c.from); !got.Equal(c.want) {
+			t.Errorf("%q.Next(%v) = %v, want %v", c.spec, c.from, got, c.want)
+		}
+	}
+}
+
+// 夏令时:跳过的时间不执行,重复的时间时字段为*时执行两次,否则只执行一次
+func TestCronNextDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	edt := time.FixedZone("EDT", -4*3600)
+	est := time.FixedZone("EST", -5*3600)
+	cases := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"0 0 3 * * ?", time.Date(2024, 3, 10, 0, 0, 0, 0, ny), time.Date(2024, 3, 10, 3, 0, 0, 0, edt)},
+		{"0 30 2 * * ?", time.Date(2024, 3, 10, 0, 0, 0, 0, ny), time.Date(2024, 3, 11, 2, 30, 0, 0, edt)},
+		{"0 */30 * * * ?", time.Date(2024, 3, 10, 1, 30, 0, 0, ny), time.Date(2024, 3, 10, 3, 0, 0, 0, edt)},
+		{"0 30 1 * * ?", time.Date(2024, 11, 3, 1, 30, 0, 0, edt), time.Date(2024, 11, 4, 1, 30, 0, 0, est)},
+		{"0 30 * * * ?", time.Date(2024, 11, 3, 1, 30, 0, 0, edt), time.Date(2024, 11, 3, 1, 30, 0, 0, est)},
+	}
+	for _, c := range cases {
+		s, err := parseCron(c.spec)
+		if err != nil {
+			t.Fatalf("parseCron(%q) error: %v", c.spec, err)
+		}
+		got := make(chan time.Time, 1)
+		go func() { got <- s.Next(c.from.In(ny)) }()
+		select {
+		case v := <-got:
+			if !v.Equal(c.want) {
+				t.Errorf("%q.Next(%v) = %v, want %v", c.spec, c.from, v, c.want)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("%q.Next(%v) did not return", c.spec, c.from)
+		}
+	}
+
+	// 零点被跳过
+	sp, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Skip(err)
+	}
+	s, _ := parseCron("0 0 12 4 11 ?")
+	want := time.Date(2018, 11, 4, 12, 0, 0, 0, time.FixedZone("-02", -2*3600))
+	if got := s.Next(time.Date(2018, 11, 3, 0, 0, 0, 0, sp)); !got.Equal(want) {
+		t.Errorf("Next across skipped midnight = %v, want %v", got, want)
+	}
+}
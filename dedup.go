@@ -0,0 +1,49 @@
+package xxl
+
+import (
+	"sync"
+	"time"
+)
+
+// 最近接受的调度日志ID,调度中心超时重试同一LogID时不重复执行
+type dedup struct {
+	mu        sync.Mutex
+	seen      map[int64]time.Time //LogID -> 接受时间
+	lastSweep time.Time
+}
+
+func newDedup() *dedup {
+	return &dedup{seen: make(map[int64]time.Time)}
+}
+
+// 是否在保留时间内已接受过
+func (d *dedup) exists(logID int64, window time.Duration, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	at, ok := d.seen[logID]
+	return ok && now.Sub(at) < window
+}
+
+// 同一LogID在保留时间内已接受过,返回原来的接受结果,不再执行
+func (e *executor) isDuplicate(param *RunReq) bool {
+	if param.LogID == 0 || e.opts.DedupWindow <= 0 {
+		return false
+	}
+	return e.dedup.exists(param.LogID, e.opts.DedupWindow, time.Now())
+}
+
+// 记录已接受的调度,并清理过期记录
+func (d *dedup) add(logID int64, window time.Duration, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.seen[logID] = now
+	if now.Sub(d.lastSweep) < window {
+		return
+	}
+	for id, at := range d.seen {
+		if now.Sub(at) >= window {
+			delete(d.seen, id)
+		}
+	}
+	d.lastSweep = now
+}
@@ -0,0 +1,69 @@
+package xxl
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	base := time.Now()
+	window := time.Minute
+	cases := []struct {
+		name  string
+		added map[int64]time.Duration //LogID -> 相对base的接受时间
+		check int64
+		at    time.Duration
+		dup   bool
+		kept  int //清理后保留的记录数,-1为不检查
+	}{
+		{"duplicate inside window", map[int64]time.Duration{1: 0}, 1, 30 * time.Second, true, -1},
+		{"other log id", map[int64]time.Duration{1: 0}, 2, time.Second, false, -1},
+		{"allowed after window", map[int64]time.Duration{1: 0}, 1, window, false, -1},
+		{"sweep removes expired entries", map[int64]time.Duration{1: 0, 2: 10 * time.Second, 3: 2 * window}, 3, 2 * window, true, 1},
+		{"no sweep inside window", map[int64]time.Duration{1: 0, 2: 30 * time.Second}, 1, 30 * time.Second, true, 2},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d := newDedup()
+			d.lastSweep = base
+			for _, id := range []int64{1, 2, 3} {
+				if at, ok := c.added[id]; ok {
+					d.add(id, window, base.Add(at))
+				}
+			}
+			if got := d.exists(c.check, window, base.Add(c.at)); got != c.dup {
+				t.Fatalf("exists(%d) = %v, want %v", c.check, got, c.dup)
+			}
+			if c.kept >= 0 && len(d.seen) != c.kept {
+				t.Fatalf("kept %d entries, want %d", len(d.seen), c.kept)
+			}
+		})
+	}
+}
+
+// 开启去重后相同LogID只执行一次,默认不去重
+func TestDuplicateTrigger(t *testing.T) {
+	for _, window := range []time.Duration{0, time.Minute} {
+		e := newExecutor(DedupWindow(window))
+		e.Init(Standalone())
+		runs := make(chan struct{}, 2)
+		e.RegTask("task.test", func(cxt context.Context, param *RunReq) string {
+			runs <- struct{}{}
+			return ""
+		})
+		for i := 0; i < 2; i++ {
+			if _, ok := e.trigger(&RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.test"}, true); !ok {
+				t.Fatalf("window %v: trigger %d rejected", window, i)
+			}
+			waitFor(t, func() bool { return len(e.Running()) == 0 })
+		}
+		want := 2
+		if window > 0 {
+			want = 1
+		}
+		if len(runs) != want || e.Metrics().Duplicated != int64(2-want) {
+			t.Fatalf("window %v: runs = %d, want %d", window, len(runs), want)
+		}
+	}
+}
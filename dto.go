@@ -1,5 +1,7 @@
 package xxl
 
+import "encoding/json"
+
 //通用响应
 type res struct {
 	Code int64       `json:"code"` // 200 表示正常、其他失败
@@ -33,6 +35,21 @@ type ExecuteResult struct {
 	Msg  interface{} `json:"msg"`
 }
 
+//触发子任务请求参数
+type childTriggerReq struct {
+	JobID         int64  `json:"jobId"`         // 子任务ID
+	ExecutorParam string `json:"executorParam"` // 子任务参数,为空时使用任务配置的参数
+	ParentJobID   int64  `json:"parentJobId"`   // 父任务ID
+	ParentLogID   int64  `json:"parentLogId"`   // 父任务调度日志ID
+}
+
+//触发子任务响应,content为子任务调度日志ID
+type childTriggerRes struct {
+	Code    int64           `json:"code"`
+	Msg     interface{}     `json:"msg"`
+	Content json.RawMessage `json:"content"`
+}
+
 /*****************  下行参数  *********************/
 
 //阻塞处理策略
@@ -61,6 +78,7 @@ type RunReq struct {
 //终止任务请求参数
 type killReq struct {
 	JobID int64 `json:"jobId"` // 任务ID
+	LogID int64 `json:"logId"` // 调度日志ID,大于零时只终止该次调度
 }
 
 //忙碌检测请求参数
@@ -0,0 +1,110 @@
+package xxl
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType 事件类型
+type EventType string
+
+// 事件类型
+const (
+	EventTaskStart         EventType = "task_start"         //任务开始执行
+	EventTaskFinish        EventType = "task_finish"        //任务执行完成(含失败、终止)
+	EventTaskPanic         EventType = "task_panic"         //任务panic
+	EventTaskProgress      EventType = "task_progress"      //任务上报进度
+	EventTaskRejected      EventType = "task_rejected"      //调度被拒绝(未注册、阻塞策略等)
+	EventTaskKilled        EventType = "task_killed"        //任务被终止(kill、覆盖之前调度)
+	EventCallbackFailed    EventType = "callback_failed"    //任务结果回调调度中心失败
+	EventRegistryFailed    EventType = "registry_failed"    //注册失败
+	EventRegistryLost      EventType = "registry_lost"      //注册成功后首次失败,调度中心可能已看不到该执行器
+	EventRegistryRecovered EventType = "registry_recovered" //注册失败后恢复
+)
+
+// Event 事件
+type Event struct {
+	Type     EventType     `json:"type"`
+	Time     time.Time     `json:"time"`
+	JobID    int64         `json:"jobId,omitempty"`
+	LogID    int64         `json:"logId,omitempty"`
+	Handler  string        `json:"handler,omitempty"`
+	Code     int64         `json:"code,omitempty"`     //任务结果码
+	Msg      string        `json:"msg,omitempty"`      //结果信息、拒绝原因、panic信息
+	Duration time.Duration `json:"duration,omitempty"` //任务执行时长
+	Addr     string        `json:"addr,omitempty"`     //调度中心地址(注册目标名称)
+	Err      error         `json:"-"`
+}
+
+// EventListener 事件监听
+type EventListener interface {
+	OnEvent(event Event)
+}
+
+// EventListenerFunc 函数形式的事件监听
+type EventListenerFunc func(event Event)
+
+// OnEvent 事件处理
+func (f EventListenerFunc) OnEvent(event Event) {
+	f(event)
+}
+
+// 事件监听列表
+type listeners struct {
+	mu   sync.RWMutex
+	list []EventListener
+}
+
+// AddListener 添加事件监听,事件在触发的goroutine中同步调用,派发时不持有执行器的锁,监听器中可调用执行器方法
+func (e *executor) AddListener(listeners ...EventListener) {
+	e.listeners.mu.Lock()
+	e.listeners.list = append(e.listeners.list, listeners...)
+	e.listeners.mu.Unlock()
+}
+
+// 派发事件,监听器panic不影响执行器
+func (e *executor) emit(event Event) {
+	e.listeners.mu.RLock()
+	list := e.listeners.list
+	e.listeners.mu.RUnlock()
+	if len(list) == 0 {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	for _, l := range list {
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					e.log.Error("事件监听panic", F("event", event.Type), F("panic", err))
+				}
+			}()
+			l.OnEvent(event)
+		}()
+	}
+}
+
+// 持有e.mu时产生的事件,解锁后再派发,避免监听器调用执行器时死锁或阻塞调度
+type eventQueue []Event
+
+func (q *eventQueue) add(event Event) {
+	*q = append(*q, event)
+}
+
+// 依次派发事件,调用时不能持有e.mu
+func (e *executor) emitAll(events eventQueue) {
+	for _, event := range events {
+		e.emit(event)
+	}
+}
+
+// 任务事件
+func runEvent(typ EventType, param *RunReq) Event {
+	return Event{
+		Type:    typ,
+		JobID:   param.JobID,
+		LogID:   param.LogID,
+		Handler: param.ExecutorHandler,
+	}
+}
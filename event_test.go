@@ -0,0 +1,50 @@
+package xxl
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 监听器中调用执行器方法不能死锁
+func TestListenerCallsExecutor(t *testing.T) {
+	e := newExecutor()
+	e.Init(Standalone())
+	e.RegTask("task.wait", func(cxt context.Context, param *RunReq) string {
+		<-cxt.Done()
+		return "done"
+	})
+	var mu sync.Mutex
+	seen := map[EventType]int{}
+	e.AddListener(EventListenerFunc(func(ev Event) {
+		mu.Lock()
+		seen[ev.Type]++
+		mu.Unlock()
+		switch ev.Type {
+		case EventTaskStart, EventTaskRejected, EventTaskKilled:
+			e.Running()
+			e.KillJob(ev.JobID)
+		}
+	}))
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		e.trigger(&RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.wait"}, true)
+		e.trigger(&RunReq{JobID: 2, LogID: 2, ExecutorHandler: "task.missing"}, true)
+		e.trigger(&RunReq{JobID: 3, LogID: 3, ExecutorHandler: "task.wait"}, true)
+		e.trigger(&RunReq{JobID: 3, LogID: 4, ExecutorHandler: "task.wait", ExecutorBlockStrategy: coverEarly}, true)
+		e.KillAll()
+	}()
+	select {
+	case <-finished:
+	case <-time.After(3 * time.Second):
+		t.Fatal("executor deadlocked by listener")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if seen[EventTaskRejected] == 0 {
+		t.Errorf("no rejected event: %v", seen)
+	}
+}
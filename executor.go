@@ -3,8 +3,9 @@ package xxl
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
-	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -22,22 +23,56 @@ type Executor interface {
 	LogHandler(handler LogHandler)
 	// Use 使用中间件
 	Use(middlewares ...Middleware)
-	// RegTask 注册任务
-	RegTask(pattern string, task TaskFunc)
+	// RegTask 注册任务,重复注册时替换handler,正在运行的旧版本继续执行完成;中间件构造失败或选项错误时不注册
+	RegTask(pattern string, task TaskFunc, opts ...TaskOption)
+	// UnregTask 注销任务,不影响正在运行的任务
+	UnregTask(pattern string)
+	// ListTask 已注册的任务列表
+	ListTask() []string
 	// RunTask 运行任务
 	RunTask(writer http.ResponseWriter, request *http.Request)
 	// KillTask 杀死任务
 	KillTask(writer http.ResponseWriter, request *http.Request)
+	// KillJob 终止任务的全部运行实例
+	KillJob(jobID int64) []KillResult
+	// KillLog 按调度日志ID终止
+	KillLog(logID int64) []KillResult
+	// KillHandler 终止handler的全部运行实例
+	KillHandler(handler string) []KillResult
+	// KillAll 终止全部运行实例
+	KillAll() []KillResult
 	// TaskLog 任务日志
 	TaskLog(writer http.ResponseWriter, request *http.Request)
 	// Beat 心跳检测
 	Beat(writer http.ResponseWriter, request *http.Request)
 	// IdleBeat 忙碌检测
 	IdleBeat(writer http.ResponseWriter, request *http.Request)
+	// Handler 包含全部路由的http.Handler,可挂载到已有服务
+	Handler() http.Handler
 	// Run 运行服务
 	Run() error
 	// Stop 停止服务
 	Stop()
+	// RegistryStatus 注册状态
+	RegistryStatus() RegistryStatus
+	// Metrics 执行器指标
+	Metrics() Metrics
+	// Zombies 已终止但任务函数仍未返回的任务
+	Zombies() []RunInfo
+	// Running 运行中的任务
+	Running() []RunInfo
+	// History 最近完成的任务
+	History() []RunRecord
+	// AddListener 添加事件监听
+	AddListener(listeners ...EventListener)
+	// Schedule 按cron表达式在本地调度任务,不经过调度中心,返回调度ID
+	Schedule(spec string, param RunReq) (int64, error)
+	// Unschedule 删除本地调度
+	Unschedule(id int64)
+	// Schedules 本地调度列表
+	Schedules() []ScheduleInfo
+	// ResourceUsage 资源使用情况,开启资源保护时定时采集
+	ResourceUsage() ResourceUsage
 }
 
 // NewExecutor 创建执行器
@@ -49,17 +84,52 @@ func newExecutor(opts ...Option) *executor {
 	options := newOptions(opts...)
 	e := &executor{
 		opts: options,
+		log:  newSysLogger(options),
+		regList: &taskList{
+			data: make(map[string]*Task),
+		},
+		runList: &taskList{
+			data: make(map[string]*Task),
+		},
+		zombieList: &taskList{
+			data: make(map[string]*Task),
+		},
+		history: newHistory(options.HistorySize),
+		reg:     &registry{status: make(map[string]*AdminStatus)},
+		sched:   newScheduler(),
+		dedup:   newDedup(),
+		pool:    &pool{},
+		guard:   &resourceGuard{},
+		done:    make(chan struct{}),
 	}
 	return e
 }
 
 type executor struct {
 	opts    Options
-	address string
+	address string    //注册地址 host:port
 	regList *taskList //注册任务列表
 	runList *taskList //正在执行任务列表
-	mu      sync.RWMutex
-	log     Logger
+
+	zombieList *taskList //已终止但未退出的任务列表
+	counters   counters  //指标计数
+	history    *history  //最近完成记录
+	listeners  listeners //事件监听
+	mu         sync.RWMutex
+	log        *sysLogger
+	client     *http.Client //调度中心请求客户端
+	admins     []string     //调度中心地址列表
+
+	registrars []Registrar //注册目标
+
+	reg      *registry       //注册状态
+	sched    *scheduler      //本地调度
+	ckpt     CheckpointStore //断点存储
+	dedup    *dedup          //已接受的调度日志ID
+	pool     *pool           //任务执行池
+	guard    *resourceGuard  //资源采集
+	stopOnce sync.Once       //停止
+	done     chan struct{}   //停止信号
 
 	logHandler  LogHandler   //日志查询handler
 	middlewares []Middleware //中间件
@@ -69,15 +139,52 @@ func (e *executor) Init(opts ...Option) {
 	for _, o := range opts {
 		o(&e.opts)
 	}
-	e.log = e.opts.l
-	e.regList = &taskList{
-		data: make(map[string]*Task),
+	e.log = newSysLogger(e.opts)
+	e.history = newHistory(e.opts.HistorySize)
+	e.ckpt = newCheckpointStore(e.opts)
+	e.client = e.opts.client
+	if e.client == nil {
+		e.client = &http.Client{
+			Timeout:   e.opts.Timeout,
+			Transport: e.opts.transport,
+		}
+	}
+	if e.opts.Interface != "" || e.opts.CIDR != "" || e.opts.IPv6 {
+		ip, err := selectIP(e.opts.Interface, e.opts.CIDR, e.opts.IPv6)
+		if err != nil {
+			e.log.Error("执行器IP选择失败", F("executor_ip", e.opts.ExecutorIp), F(FieldError, err))
+		} else {
+			e.opts.ExecutorIp = ip
+		}
+	}
+	host, port := e.opts.ExecutorIp, e.opts.ExecutorPort
+	if e.opts.AdvertiseHost != "" {
+		host = strings.Trim(e.opts.AdvertiseHost, "[]")
+	}
+	if e.opts.AdvertisePort != "" {
+		port = e.opts.AdvertisePort
+	}
+	e.address = net.JoinHostPort(host, port)
+	if !e.opts.Standalone {
+		e.admins = adminAddrs(e.opts.ServerAddr)
+	}
+	if err := checkAdminVersion(e.opts.AdminVersion); err != nil {
+		e.log.Error("调度中心版本错误,使用兼容模式", F(FieldError, err))
+		e.opts.AdminVersion = AdminVersionCompat
 	}
-	e.runList = &taskList{
-		data: make(map[string]*Task),
+	e.registrars = e.opts.registrars
+	if e.opts.Standalone {
+		e.registrars = nil
+	} else if len(e.registrars) == 0 {
+		for _, addr := range e.admins {
+			e.registrars = append(e.registrars, &adminRegistrar{e: e, addr: addr})
+		}
+	}
+	if isIsolatedChild() {
+		return
 	}
-	e.address = e.opts.ExecutorIp + ":" + e.opts.ExecutorPort
-	go e.registry()
+	e.startResourceGuard()
+	e.startRegistry()
 }
 
 // LogHandler 日志handler
@@ -90,134 +197,310 @@ func (e *executor) Use(middlewares ...Middleware) {
 }
 
 func (e *executor) Run() (err error) {
-	// 创建路由器
-	mux := http.NewServeMux()
-	// 设置路由规则
-	mux.HandleFunc("/run", e.runTask)
-	mux.HandleFunc("/kill", e.killTask)
-	mux.HandleFunc("/log", e.taskLog)
-	mux.HandleFunc("/beat", e.beat)
-	mux.HandleFunc("/idleBeat", e.idleBeat)
+	if isIsolatedChild() {
+		e.runIsolatedChild()
+	}
 	// 创建服务器
 	server := &http.Server{
-		Addr:         ":" + e.opts.ExecutorPort,
-		WriteTimeout: time.Second * 3,
-		Handler:      mux,
+		Addr:         net.JoinHostPort(e.opts.ListenIp, e.opts.ExecutorPort),
+		ReadTimeout:  e.opts.ReadTimeout,
+		WriteTimeout: e.opts.WriteTimeout,
+		IdleTimeout:  e.opts.IdleTimeout,
+		TLSConfig:    e.opts.tlsConfig,
+		Handler:      e.Handler(),
 	}
 	// 监听端口并提供服务
-	e.log.Info("Starting server at " + e.address)
-	go server.ListenAndServe()
-	quit := make(chan os.Signal)
+	ln := e.opts.listener
+	if ln == nil {
+		ln, err = net.Listen("tcp", server.Addr)
+		if err != nil {
+			return err
+		}
+	}
+	e.log.Info("Starting server", F("listen", ln.Addr().String()), F("registry", e.registryValue()))
+	errCh := make(chan error, 1)
+	go func() {
+		if e.useTLS() {
+			errCh <- server.ServeTLS(ln, e.opts.TLSCertFile, e.opts.TLSKeyFile)
+		} else {
+			errCh <- server.Serve(ln)
+		}
+	}()
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGKILL, syscall.SIGQUIT, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	e.registryRemove()
-	return nil
+	select {
+	case <-quit:
+	case <-e.done:
+	case err = <-errCh:
+		e.log.Error("服务异常退出", F(FieldError, err))
+	}
+	e.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	_ = server.Shutdown(ctx)
+	return err
+}
+
+// Handler 包含全部路由的http.Handler,路由挂载在BasePath下
+func (e *executor) Handler() http.Handler {
+	// 创建路由器
+	mux := http.NewServeMux()
+	// 设置路由规则
+	prefix := e.basePath()
+	mux.HandleFunc(prefix+"/run", e.runTask)
+	mux.HandleFunc(prefix+"/kill", e.killTask)
+	mux.HandleFunc(prefix+"/log", e.taskLog)
+	mux.HandleFunc(prefix+"/beat", e.beat)
+	mux.HandleFunc(prefix+"/idleBeat", e.idleBeat)
+	if e.opts.DebugEndpoint {
+		mux.HandleFunc(prefix+"/debug/runs", e.debugRuns)
+	}
+	return mux
+}
+
+// 是否启用HTTPS
+func (e *executor) useTLS() bool {
+	return e.opts.TLSCertFile != "" || e.opts.tlsConfig != nil
+}
+
+// 规范化的路由前缀,以/开头且不以/结尾
+func (e *executor) basePath() string {
+	p := strings.Trim(e.opts.BasePath, "/")
+	if p == "" {
+		return ""
+	}
+	return "/" + p
 }
 
+// 注册到调度中心的执行器地址
+func (e *executor) registryValue() string {
+	if e.opts.AdvertiseAddr != "" {
+		return e.opts.AdvertiseAddr
+	}
+	scheme := "http://"
+	if e.useTLS() {
+		scheme = "https://"
+	}
+	return scheme + e.address + e.basePath()
+}
+
+// Stop 停止注册并从调度中心摘除,Run随之返回
 func (e *executor) Stop() {
-	e.registryRemove()
+	e.stopOnce.Do(func() {
+		e.stopRegistry()
+		e.registryRemove()
+		close(e.done)
+	})
 }
 
 // RegTask 注册任务
-func (e *executor) RegTask(pattern string, task TaskFunc) {
+func (e *executor) RegTask(pattern string, task TaskFunc, opts ...TaskOption) {
 	var t = &Task{}
-	t.fn = e.chain(task)
+	for _, o := range opts {
+		o(&t.opts)
+	}
+	if t.opts.err != nil {
+		e.log.Error("任务注册失败,任务选项错误", F(FieldHandler, pattern), F(FieldError, t.opts.err))
+		return
+	}
+	fn, err := e.chain(task)
+	if err != nil {
+		e.log.Error("任务注册失败,中间件构造错误", F(FieldHandler, pattern), F(FieldError, err))
+		return
+	}
+	t.fn = fn
 	e.regList.Set(pattern, t)
+	e.log.Info("任务注册", F(FieldHandler, pattern))
 	return
 }
 
+// UnregTask 注销任务
+func (e *executor) UnregTask(pattern string) {
+	if !e.regList.Exists(pattern) {
+		return
+	}
+	e.regList.Del(pattern)
+	e.log.Info("任务注销", F(FieldHandler, pattern))
+}
+
+// ListTask 已注册的任务列表
+func (e *executor) ListTask() []string {
+	return e.regList.Keys()
+}
+
 // 运行一个任务
 func (e *executor) runTask(writer http.ResponseWriter, request *http.Request) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-
-	req, _ := ioutil.ReadAll(request.Body)
 	param := &RunReq{}
-	err := json.Unmarshal(req, &param)
-	if err != nil {
-		_, _ = writer.Write(returnCall(param, FailureCode, "params err"))
-		e.log.Error("参数解析错误:" + string(req))
+	if err := e.decode(writer, request, param); err != nil {
+		writeError(writer, err)
+		e.log.Error("参数解析错误", F(FieldError, err))
 		return
 	}
-	e.log.Info("任务参数:%v", param)
-	if !e.regList.Exists(param.ExecutorHandler) {
-		_, _ = writer.Write(returnCall(param, FailureCode, "Task not registered"))
-		e.log.Error("任务[" + Int64ToStr(param.JobID) + "]没有注册:" + param.ExecutorHandler)
+	if err := param.validate(); err != nil {
+		writeError(writer, err)
+		e.log.Error("参数校验错误", append(runFields(param), F(FieldError, err))...)
 		return
 	}
+	if msg, ok := e.trigger(param, false); !ok {
+		_, _ = writer.Write(returnFailure(msg))
+		return
+	}
+	_, _ = writer.Write(returnGeneral())
+}
+
+// 触发一次调度,local为本地调度(不回调调度中心),被拒绝时返回原因
+func (e *executor) trigger(param *RunReq, local bool) (msg string, ok bool) {
+	var events eventQueue
+	e.mu.Lock()
+	msg, ok = e.admit(param, local, &events)
+	e.mu.Unlock()
+	e.emitAll(events) //解锁后派发,监听器中可调用执行器
+	return msg, ok
+}
+
+// 按去重、阻塞策略、限流等规则接受调度并提交执行,需持有e.mu,产生的事件加入events
+func (e *executor) admit(param *RunReq, local bool, events *eventQueue) (msg string, ok bool) {
+	e.log.Debug("任务参数", append(runFields(param), F("params", param.ExecutorParams))...)
+	if e.isDuplicate(param) {
+		e.log.Warn("重复调度,已忽略", runFields(param)...)
+		e.counters.add(&e.counters.duplicated)
+		return "", true
+	}
+	handler := e.regList.Get(param.ExecutorHandler)
+	if handler == nil {
+		e.log.Error("任务没有注册", runFields(param)...)
+		return e.reject(events, param, "Task not registered"), false
+	}
+
+	if e.opts.RejectZombie && len(e.zombieJob(param.JobID)) > 0 {
+		e.log.Warn("任务终止后仍在运行,拒绝调度", runFields(param)...)
+		return e.reject(events, param, "There are killed tasks still running"), false
+	}
+
+	if reason, over := e.overloaded(); over {
+		e.log.Warn("执行器资源不足,拒绝调度", append(runFields(param), F("reason", reason))...)
+		e.counters.add(&e.counters.overloaded)
+		return e.reject(events, param, "Executor overloaded: "+reason), false
+	}
+
+	//限流在阻塞策略之前,被限流的调度不能覆盖正在运行的任务
+	delay, allowed := handler.opts.limiter.reserve()
+	if !allowed {
+		e.log.Warn("任务限流,拒绝调度", runFields(param)...)
+		e.counters.add(&e.counters.limited)
+		return e.reject(events, param, "Rate limit exceeded"), false
+	}
 
 	//阻塞策略处理
-	if e.runList.Exists(Int64ToStr(param.JobID)) {
+	if oldTasks := e.runningJob(param.JobID); len(oldTasks) > 0 {
 		if param.ExecutorBlockStrategy == coverEarly { //覆盖之前调度
-			oldTask := e.runList.Get(Int64ToStr(param.JobID))
-			if oldTask != nil {
-				oldTask.Cancel()
-				e.runList.Del(Int64ToStr(oldTask.Id))
+			for _, oldTask := range oldTasks {
+				e.killOne(oldTask, events)
 			}
 		} else { //单机串行,丢弃后续调度 都进行阻塞
-			_, _ = writer.Write(returnCall(param, FailureCode, "There are tasks running"))
-			e.log.Error("任务[" + Int64ToStr(param.JobID) + "]已经在运行了:" + param.ExecutorHandler)
-			return
+			handler.opts.limiter.cancel()
+			e.log.Warn("任务已经在运行了", runFields(param)...)
+			return e.reject(events, param, "There are tasks running"), false
 		}
 	}
 
-	cxt := context.Background()
-	task := e.regList.Get(param.ExecutorHandler)
-	if param.ExecutorTimeout > 0 {
-		task.Ext, task.Cancel = context.WithTimeout(cxt, time.Duration(param.ExecutorTimeout)*time.Second)
-	} else {
-		task.Ext, task.Cancel = context.WithCancel(cxt)
+	if delay > 0 {
+		e.log.Info("任务限流,延迟执行", append(runFields(param), F("delay", delay))...)
+		e.counters.add(&e.counters.delayed)
+	}
+
+	//每次调度使用独立的Task,替换handler不影响正在运行的任务
+	task := &Task{fn: handler.fn, opts: handler.opts, done: make(chan struct{}), local: local}
+	cxt := context.WithValue(context.Background(), taskCtxKey{}, task)
+	if task.opts.isolated {
+		task.runner = e.runIsolated
 	}
+	task.Ext, task.Cancel = context.WithCancel(cxt)
 	task.Id = param.JobID
 	task.Name = param.ExecutorHandler
 	task.Param = param
-	task.log = e.log
+	task.log = e.log.With(runFields(param)...)
+	task.emit = e.emit
+	task.jlog = e.openJobLog(param)
+	task.onPanic = e.handlePanic
+	if e.opts.ChildTriggerPath != "" {
+		task.onChild = e.triggerChildren
+	}
+	task.onProgress = e.onProgress
+	task.ckpt = e.ckpt
+	task.limitDelay = delay
 
-	e.runList.Set(Int64ToStr(task.Id), task)
-	go task.Run(func(code int64, msg string) {
-		e.callback(task, code, msg)
+	e.runList.Set(Int64ToStr(param.LogID), task)
+	if param.LogID != 0 && e.opts.DedupWindow > 0 {
+		e.dedup.add(param.LogID, e.opts.DedupWindow, time.Now())
+	}
+	e.counters.add(&e.counters.triggered)
+	e.submit(task, func() {
+		//排队或限流等待中被终止的任务不算开始,超时从取得并发开始计算
+		if task.Ext.Err() == nil {
+			timeout := time.Duration(param.ExecutorTimeout) * time.Second
+			defer task.start(timeout)()
+			if timeout > 0 {
+				go e.watchTimeout(task)
+			}
+			e.log.Info("任务开始执行", runFields(param)...)
+			e.emit(runEvent(EventTaskStart, param))
+		}
+		task.Run(func(code int64, msg string) {
+			e.callback(task, code, msg)
+		})
 	})
-	e.log.Info("任务[" + Int64ToStr(param.JobID) + "]开始执行:" + param.ExecutorHandler)
-	_, _ = writer.Write(returnGeneral())
+	return "", true
+}
+
+// 拒绝调度
+func (e *executor) reject(events *eventQueue, param *RunReq, msg string) string {
+	e.counters.add(&e.counters.rejected)
+	ev := runEvent(EventTaskRejected, param)
+	ev.Code, ev.Msg = FailureCode, msg
+	events.add(ev)
+	return msg
 }
 
 // 删除一个任务
 func (e *executor) killTask(writer http.ResponseWriter, request *http.Request) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-	req, _ := ioutil.ReadAll(request.Body)
 	param := &killReq{}
-	_ = json.Unmarshal(req, &param)
-	if !e.runList.Exists(Int64ToStr(param.JobID)) {
+	if err := e.decode(writer, request, param); err != nil {
+		writeError(writer, err)
+		e.log.Error("参数解析错误", F(FieldError, err))
+		return
+	}
+	match := func(t *Task) bool { return t.Id == param.JobID }
+	if param.LogID > 0 {
+		match = func(t *Task) bool { return t.Param.LogID == param.LogID }
+	}
+	results := e.kill(match, e.killWait())
+	if len(results) == 0 {
 		_, _ = writer.Write(returnKill(param, FailureCode))
-		e.log.Error("任务[" + Int64ToStr(param.JobID) + "]没有运行")
+		e.log.Warn("任务没有运行", F(FieldJobID, param.JobID), F(FieldLogID, param.LogID))
+		return
+	}
+	if e.opts.KillGrace > 0 && !allStopped(results) {
+		_, _ = writer.Write(returnFailure("Task still running after kill"))
 		return
 	}
-	task := e.runList.Get(Int64ToStr(param.JobID))
-	task.Cancel()
-	e.runList.Del(Int64ToStr(param.JobID))
 	_, _ = writer.Write(returnGeneral())
 }
 
 // 任务日志
 func (e *executor) taskLog(writer http.ResponseWriter, request *http.Request) {
 	var res *LogRes
-	data, err := ioutil.ReadAll(request.Body)
 	req := &LogReq{}
-	if err != nil {
-		e.log.Error("日志请求失败:" + err.Error())
-		reqErrLogHandler(writer, req, err)
-		return
-	}
-	err = json.Unmarshal(data, &req)
-	if err != nil {
-		e.log.Error("日志请求解析失败:" + err.Error())
+	if err := e.decode(writer, request, req); err != nil {
+		e.log.Error("日志请求解析失败", F(FieldError, err))
 		reqErrLogHandler(writer, req, err)
 		return
 	}
-	e.log.Info("日志请求参数:%+v", req)
+	e.log.Debug("日志请求参数", F(FieldLogID, req.LogID), F("from_line", req.FromLineNum))
 	if e.logHandler != nil {
 		res = e.logHandler(req)
+	} else if e.opts.LogDir != "" {
+		res = e.fileLogHandler(e.opts.LogDir)(req)
 	} else {
 		res = defaultLogHandler(req)
 	}
@@ -227,7 +510,12 @@ func (e *executor) taskLog(writer http.ResponseWriter, request *http.Request) {
 
 // 心跳检测
 func (e *executor) beat(writer http.ResponseWriter, request *http.Request) {
-	e.log.Info("心跳检测")
+	defer request.Body.Close()
+	if err := checkMethod(request); err != nil {
+		writeError(writer, err)
+		return
+	}
+	e.log.Debug("心跳检测")
 	_, _ = writer.Write(returnGeneral())
 }
 
@@ -235,118 +523,115 @@ func (e *executor) beat(writer http.ResponseWriter, request *http.Request) {
 func (e *executor) idleBeat(writer http.ResponseWriter, request *http.Request) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	defer request.Body.Close()
-	req, _ := ioutil.ReadAll(request.Body)
 	param := &idleBeatReq{}
-	err := json.Unmarshal(req, &param)
-	if err != nil {
+	if err := e.decode(writer, request, param); err != nil {
+		writeError(writer, err)
+		e.log.Error("参数解析错误", F(FieldError, err))
+		return
+	}
+	if len(e.runningJob(param.JobID)) > 0 {
+		_, _ = writer.Write(returnIdleBeat(FailureCode))
+		e.log.Debug("忙碌检测任务正在运行", F(FieldJobID, param.JobID))
+		return
+	}
+	if e.saturated() {
 		_, _ = writer.Write(returnIdleBeat(FailureCode))
-		e.log.Error("参数解析错误:" + string(req))
+		e.log.Debug("忙碌检测执行器并发已满", F(FieldJobID, param.JobID))
 		return
 	}
-	if e.runList.Exists(Int64ToStr(param.JobID)) {
+	if reason, over := e.overloaded(); over {
 		_, _ = writer.Write(returnIdleBeat(FailureCode))
-		e.log.Error("idleBeat任务[" + Int64ToStr(param.JobID) + "]正在运行")
+		e.log.Debug("忙碌检测执行器资源不足", F(FieldJobID, param.JobID), F("reason", reason))
 		return
 	}
-	e.log.Info("忙碌检测任务参数:%v", param)
+	e.log.Debug("忙碌检测", F(FieldJobID, param.JobID))
 	_, _ = writer.Write(returnGeneral())
 }
 
-// 注册执行器到调度中心
-func (e *executor) registry() {
-
-	t := time.NewTimer(time.Second * 0) //初始立即执行
-	defer t.Stop()
-	req := &Registry{
-		RegistryGroup: "EXECUTOR",
-		RegistryKey:   e.opts.RegistryKey,
-		RegistryValue: "http://" + e.address,
+// 回调任务列表
+func (e *executor) callback(task *Task, code int64, msg string) {
+	e.runList.Del(Int64ToStr(task.Param.LogID))
+	if e.zombieList.Exists(Int64ToStr(task.Param.LogID)) {
+		e.zombieList.Del(Int64ToStr(task.Param.LogID))
+		e.log.Debug("已终止任务退出", runFields(task.Param)...)
 	}
-	param, err := json.Marshal(req)
-	if err != nil {
-		log.Fatal("执行器注册信息解析失败:" + err.Error())
-	}
-	for {
-		<-t.C
-		t.Reset(time.Second * time.Duration(20)) //20秒心跳防止过期
-		func() {
-			result, err := e.post("/api/registry", string(param))
-			if err != nil {
-				e.log.Error("执行器注册失败1:" + err.Error())
-				return
-			}
-			defer result.Body.Close()
-			body, err := ioutil.ReadAll(result.Body)
-			if err != nil {
-				e.log.Error("执行器注册失败2:" + err.Error())
-				return
-			}
-			res := &res{}
-			_ = json.Unmarshal(body, &res)
-			if res.Code != SuccessCode {
-				e.log.Error("执行器注册失败3:" + string(body))
-				return
-			}
-			e.log.Info("执行器注册成功:" + string(body))
-		}()
-
+	task.EndTime = time.Now().UnixNano() / int64(time.Millisecond)
+	e.release(task)
+	if code == SuccessCode {
+		e.counters.add(&e.counters.succeeded)
+	} else {
+		e.counters.add(&e.counters.failed)
 	}
-}
-
-// 执行器注册摘除
-func (e *executor) registryRemove() {
-	t := time.NewTimer(time.Second * 0) //初始立即执行
-	defer t.Stop()
-	req := &Registry{
-		RegistryGroup: "EXECUTOR",
-		RegistryKey:   e.opts.RegistryKey,
-		RegistryValue: "http://" + e.address,
+	e.record(task, code, msg)
+	ev := runEvent(EventTaskFinish, task.Param)
+	ev.Code, ev.Msg = code, msg
+	ev.Duration = task.duration()
+	e.emit(ev)
+	if task.local {
+		e.log.Info("本地任务执行完成", append(runFields(task.Param), F("code", code), F("msg", msg))...)
+		return
 	}
-	param, err := json.Marshal(req)
-	if err != nil {
-		e.log.Error("执行器摘除失败:" + err.Error())
+	param := encodeCall(e.opts.AdminVersion, task.Param, code, msg)
+	var err error
+	for _, addr := range e.admins {
+		var body []byte
+		body, err = e.adminCall(context.Background(), addr, "/api/callback", param)
+		if err != nil {
+			e.log.Error("任务回调失败", append(runFields(task.Param), F(FieldAdminAddr, addr), F(FieldError, err))...)
+			continue
+		}
+		e.log.Info("任务回调成功", append(runFields(task.Param), F(FieldAdminAddr, addr), F("response", string(body)))...)
 		return
 	}
-	res, err := e.post("/api/registryRemove", string(param))
 	if err != nil {
-		e.log.Error("执行器摘除失败:" + err.Error())
-		return
+		ev = runEvent(EventCallbackFailed, task.Param)
+		ev.Code, ev.Msg, ev.Err = code, msg, err
+		e.emit(ev)
 	}
-	defer res.Body.Close()
-	body, err := ioutil.ReadAll(res.Body)
-	e.log.Info("执行器摘除成功:" + string(body))
 }
 
-// 回调任务列表
-func (e *executor) callback(task *Task, code int64, msg string) {
-	e.runList.Del(Int64ToStr(task.Id))
-	res, err := e.post("/api/callback", string(returnCall(task.Param, code, msg)))
+// 请求调度中心并校验响应码
+func (e *executor) adminCall(ctx context.Context, addr, action string, param []byte) (body []byte, err error) {
+	result, err := e.post(ctx, addr, action, string(param))
 	if err != nil {
-		e.log.Error("callback err : ", err.Error())
-		return
+		return nil, err
 	}
-	defer res.Body.Close()
-	body, err := ioutil.ReadAll(res.Body)
+	defer result.Body.Close()
+	body, err = ioutil.ReadAll(result.Body)
 	if err != nil {
-		e.log.Error("callback ReadAll err : ", err.Error())
-		return
+		return nil, err
+	}
+	if result.StatusCode != http.StatusOK {
+		return body, fmt.Errorf("http status %d: %s", result.StatusCode, body)
+	}
+	r := &res{}
+	if err = json.Unmarshal(body, r); err != nil {
+		return body, fmt.Errorf("invalid response %s: %v", body, err)
 	}
-	e.log.Info("任务回调成功:" + string(body))
+	if r.Code != SuccessCode {
+		return body, fmt.Errorf("code %d: %v", r.Code, r.Msg)
+	}
+	return body, nil
 }
 
 // post
-func (e *executor) post(action, body string) (resp *http.Response, err error) {
-	request, err := http.NewRequest("POST", e.opts.ServerAddr+action, strings.NewReader(body))
+func (e *executor) post(ctx context.Context, addr, action, body string) (resp *http.Response, err error) {
+	request, err := http.NewRequest("POST", addr+action, strings.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
+	request = request.WithContext(ctx)
+	for k, v := range e.opts.header {
+		request.Header[k] = v
+	}
 	request.Header.Set("Content-Type", "application/json;charset=UTF-8")
 	request.Header.Set("XXL-JOB-ACCESS-TOKEN", e.opts.AccessToken)
-	client := http.Client{
-		Timeout: e.opts.Timeout,
+	for _, sign := range e.opts.signers {
+		if err = sign(request); err != nil {
+			return nil, err
+		}
 	}
-	return client.Do(request)
+	return e.client.Do(request)
 }
 
 // RunTask 运行任务
@@ -0,0 +1,143 @@
+package xxl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// 子进程隔离运行的环境变量
+const (
+	envIsolatedHandler = "XXL_JOB_ISOLATED_HANDLER" //子进程执行的任务标识
+	envIsolatedResult  = "XXL_JOB_ISOLATED_RESULT"  //子进程写入执行结果的文件
+)
+
+// 子进程执行结果
+type isolatedResult struct {
+	Code     int64      `json:"code"`
+	Msg      string     `json:"msg"`
+	Children []childJob `json:"children"` //子进程中添加的子任务
+	Progress *Progress  `json:"progress"` //子进程中最后上报的进度
+}
+
+// 当前进程是否为隔离运行的子进程
+func isIsolatedChild() bool {
+	return os.Getenv(envIsolatedHandler) != ""
+}
+
+// 在子进程中运行任务,任务取消时先发送中断信号,KillGrace后强制杀死
+func (e *executor) runIsolated(t *Task) (int64, string) {
+	exe, err := os.Executable()
+	if err != nil {
+		return FailureCode, "isolated run err:" + err.Error()
+	}
+	f, err := ioutil.TempFile("", "xxl-job-result-*")
+	if err != nil {
+		return FailureCode, "isolated run err:" + err.Error()
+	}
+	resultFile := f.Name()
+	_ = f.Close()
+	defer os.Remove(resultFile)
+	param, _ := json.Marshal(t.Param)
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), envIsolatedHandler+"="+t.Name, envIsolatedResult+"="+resultFile)
+	cmd.Stdin = bytes.NewReader(param)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err = cmd.Start(); err != nil {
+		return FailureCode, "isolated run err:" + err.Error()
+	}
+	t.log.Debug("子进程开始执行", F("pid", cmd.Process.Pid))
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+	select {
+	case err = <-exited:
+	case <-t.Ext.Done():
+		err = e.stopProcess(cmd, exited)
+	}
+
+	data, _ := ioutil.ReadFile(resultFile)
+	res := &isolatedResult{}
+	if len(data) == 0 || json.Unmarshal(data, res) != nil {
+		return FailureCode, fmt.Sprintf("isolated process exit:%v", err)
+	}
+	t.mu.Lock()
+	t.children = append(t.children, res.Children...)
+	if res.Progress != nil {
+		t.progress = res.Progress
+	}
+	t.mu.Unlock()
+	return res.Code, res.Msg
+}
+
+// 终止子进程
+func (e *executor) stopProcess(cmd *exec.Cmd, exited chan error) error {
+	if e.opts.KillGrace > 0 && cmd.Process.Signal(os.Interrupt) == nil {
+		timer := time.NewTimer(e.opts.KillGrace)
+		defer timer.Stop()
+		select {
+		case err := <-exited:
+			return err
+		case <-timer.C:
+		}
+	}
+	_ = cmd.Process.Kill()
+	e.log.Warn("子进程已强制杀死", F("pid", cmd.Process.Pid))
+	return <-exited
+}
+
+// 子进程中执行任务,结果写入文件后退出
+func (e *executor) runIsolatedChild() {
+	name := os.Getenv(envIsolatedHandler)
+	res := &isolatedResult{Code: FailureCode}
+	defer func() {
+		data, _ := json.Marshal(res)
+		_ = ioutil.WriteFile(os.Getenv(envIsolatedResult), data, 0600)
+		os.Exit(0)
+	}()
+	param := &RunReq{}
+	if err := json.NewDecoder(os.Stdin).Decode(param); err != nil {
+		res.Msg = "isolated params err:" + err.Error()
+		return
+	}
+	handler := e.regList.Get(name)
+	if handler == nil {
+		res.Msg = "Task not registered"
+		return
+	}
+	task := &Task{
+		Id:    param.JobID,
+		Name:  name,
+		Param: param,
+		fn:    handler.fn,
+		opts:  handler.opts,
+		log:   e.log.With(runFields(param)...),
+		jlog:  e.openJobLog(param),
+	}
+	task.onPanic = e.handlePanic
+	task.ckpt = e.ckpt
+	if e.opts.ChildTriggerPath != "" {
+		task.onChild = e.triggerChildren
+	}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), taskCtxKey{}, task))
+	task.Ext, task.Cancel = ctx, cancel
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-quit
+		cancel()
+	}()
+	res.Code, res.Msg = task.call()
+	res.Children = task.children
+	res.Progress = task.progress
+}
@@ -0,0 +1,113 @@
+package xxl
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+任务日志,每次调度一个文件 LogDir/yyyy-MM-dd/logId.log,未设置LogDir时不记录
+*/
+
+// 任务日志文件路径
+func jobLogPath(dir string, logDateTime, logID int64) string {
+	day := time.Unix(0, logDateTime*int64(time.Millisecond)).Format("2006-01-02")
+	return filepath.Join(dir, day, Int64ToStr(logID)+".log")
+}
+
+// 任务日志
+type jobLogger struct {
+	mu   sync.Mutex
+	path string
+}
+
+// 打开任务日志,未设置LogDir时返回nil
+func (e *executor) openJobLog(param *RunReq) *jobLogger {
+	if e.opts.LogDir == "" {
+		return nil
+	}
+	path := jobLogPath(e.opts.LogDir, param.LogDateTime, param.LogID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		e.log.Error("任务日志目录创建失败", append(runFields(param), F(FieldError, err))...)
+		return nil
+	}
+	return &jobLogger{path: path}
+}
+
+// 写入一行日志
+func (l *jobLogger) Write(format string, a ...interface{}) {
+	if l == nil {
+		return
+	}
+	line := time.Now().Format("2006-01-02 15:04:05.000") + " " + fmt.Sprintf(format, a...)
+	if !strings.HasSuffix(line, "\n") {
+		line += "\n"
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return
+	}
+	_, _ = f.WriteString(line)
+	_ = f.Close()
+}
+
+type taskCtxKey struct{}
+
+// 从任务上下文获取任务
+func taskFrom(ctx context.Context) *Task {
+	if ctx == nil {
+		return nil
+	}
+	t, _ := ctx.Value(taskCtxKey{}).(*Task)
+	return t
+}
+
+// JobLog 在任务中写入本次调度的任务日志,可在调度中心查看
+func JobLog(ctx context.Context, format string, a ...interface{}) {
+	if t := taskFrom(ctx); t != nil {
+		t.jlog.Write(format, a...)
+	}
+}
+
+// 从任务日志文件读取日志,任务仍在运行时IsEnd为false
+func (e *executor) fileLogHandler(dir string) LogHandler {
+	return func(req *LogReq) *LogRes {
+		fromLine := req.FromLineNum
+		if fromLine < 1 {
+			fromLine = 1
+		}
+		res := &LogRes{Code: SuccessCode, Content: LogResContent{FromLineNum: fromLine, ToLineNum: fromLine - 1, IsEnd: true}}
+		f, err := os.Open(jobLogPath(dir, req.LogDateTim, req.LogID))
+		if err != nil {
+			res.Code, res.Msg = FailureCode, "log file not found"
+			res.Content.LogContent = "log file not found"
+			return res
+		}
+		defer f.Close()
+		var b strings.Builder
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		line := 0
+		for scanner.Scan() {
+			line++
+			if line < fromLine {
+				continue
+			}
+			b.WriteString(scanner.Text())
+			b.WriteByte('\n')
+		}
+		res.Content.LogContent = b.String()
+		res.Content.ToLineNum = line
+		key := Int64ToStr(req.LogID)
+		res.Content.IsEnd = !e.runList.Exists(key) && !e.zombieList.Exists(key)
+		return res
+	}
+}
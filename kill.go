@@ -0,0 +1,138 @@
+package xxl
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// KillResult 终止结果
+type KillResult struct {
+	JobID   int64  `json:"jobId"`   //任务ID
+	LogID   int64  `json:"logId"`   //调度日志ID
+	Handler string `json:"handler"` //任务标识
+	Stopped bool   `json:"stopped"` //等待时间(KillGrace)内是否已停止
+}
+
+// KillJob 终止任务的全部运行实例
+func (e *executor) KillJob(jobID int64) []KillResult {
+	return e.kill(func(t *Task) bool { return t.Id == jobID }, e.opts.KillGrace)
+}
+
+// KillLog 按调度日志ID终止
+func (e *executor) KillLog(logID int64) []KillResult {
+	return e.kill(func(t *Task) bool { return t.Param.LogID == logID }, e.opts.KillGrace)
+}
+
+// KillHandler 终止handler的全部运行实例
+func (e *executor) KillHandler(handler string) []KillResult {
+	return e.kill(func(t *Task) bool { return t.Name == handler }, e.opts.KillGrace)
+}
+
+// KillAll 终止全部运行实例
+func (e *executor) KillAll() []KillResult {
+	return e.kill(func(t *Task) bool { return true }, e.opts.KillGrace)
+}
+
+// 终止匹配的任务,并在grace内等待停止
+func (e *executor) kill(match func(t *Task) bool, grace time.Duration) []KillResult {
+	var events eventQueue
+	e.mu.Lock()
+	tasks := e.runList.Find(match)
+	for _, task := range tasks {
+		e.killOne(task, &events)
+	}
+	e.mu.Unlock()
+	e.emitAll(events)
+
+	results := make([]KillResult, len(tasks))
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		go func(i int, task *Task) {
+			defer wg.Done()
+			stopped := task.wait(grace)
+			results[i] = KillResult{JobID: task.Id, LogID: task.Param.LogID, Handler: task.Name, Stopped: stopped}
+			if !stopped && grace > 0 {
+				e.log.Warn("任务终止后仍在运行", runFields(task.Param)...)
+				return
+			}
+			e.log.Info("任务终止", append(runFields(task.Param), F("stopped", stopped))...)
+		}(i, task)
+	}
+	wg.Wait()
+	return results
+}
+
+// 终止任务并移出运行列表,任务函数返回前记为已终止未退出,需持有e.mu,终止事件加入events
+func (e *executor) killOne(task *Task, events *eventQueue) {
+	task.kill()
+	e.detach(task)
+	e.counters.add(&e.counters.killed)
+	events.add(runEvent(EventTaskKilled, task.Param))
+}
+
+// 已取消的任务移出运行列表,任务函数返回前记为已终止未退出,需持有e.mu
+func (e *executor) detach(task *Task) {
+	key := Int64ToStr(task.Param.LogID)
+	e.zombieList.Set(key, task)
+	e.dequeue(task)
+	e.runList.Del(key)
+	if task.wait(0) {
+		e.zombieList.Del(key)
+	}
+}
+
+// 任务超时后函数仍未返回时,与终止的任务一样记为已终止未退出
+func (e *executor) watchTimeout(task *Task) {
+	select {
+	case <-task.done:
+		return
+	case <-task.Ext.Done():
+	}
+	if task.Ext.Err() != context.DeadlineExceeded || task.isKilled() {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if task.wait(0) || e.runList.Get(Int64ToStr(task.Param.LogID)) != task {
+		return
+	}
+	e.detach(task)
+	e.log.Warn("任务超时后仍在运行", runFields(task.Param)...)
+}
+
+// 已终止但仍未退出的任务实例
+func (e *executor) zombieJob(jobID int64) []*Task {
+	return e.zombieList.Find(func(t *Task) bool { return t.Id == jobID })
+}
+
+// 运行中的任务实例
+func (e *executor) runningJob(jobID int64) []*Task {
+	return e.runList.Find(func(t *Task) bool { return t.Id == jobID })
+}
+
+// 终止结果是否全部停止
+// /kill请求等待任务停止的时间,比服务写超时少killWriteMargin,保证调度中心能收到停止结果
+func (e *executor) killWait() time.Duration {
+	grace := e.opts.KillGrace
+	if w := e.opts.WriteTimeout; w > 0 && grace > w-killWriteMargin {
+		grace = w - killWriteMargin
+	}
+	if grace < 0 {
+		grace = 0
+	}
+	return grace
+}
+
+// 写响应预留时间
+const killWriteMargin = 500 * time.Millisecond
+
+func allStopped(results []KillResult) bool {
+	for _, r := range results {
+		if !r.Stopped {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package xxl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeoutTracksZombie(t *testing.T) {
+	e := newExecutor(RejectZombie())
+	e.Init(Standalone())
+	release := make(chan struct{})
+	e.RegTask("task.block", func(cxt context.Context, param *RunReq) string {
+		<-release //忽略ctx
+		return "done"
+	})
+	if msg, ok := e.trigger(&RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.block", ExecutorTimeout: 1}, true); !ok {
+		t.Fatalf("trigger rejected: %s", msg)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for len(e.Zombies()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if n := len(e.Zombies()); n != 1 {
+		t.Fatalf("zombies = %d, want 1", n)
+	}
+	if m := e.Metrics(); m.Zombies != 1 || m.Running != 0 {
+		t.Fatalf("metrics = %+v, want 1 zombie and 0 running", m)
+	}
+	if _, ok := e.trigger(&RunReq{JobID: 1, LogID: 2, ExecutorHandler: "task.block"}, true); ok {
+		t.Fatal("trigger should be rejected while timed out run is still running")
+	}
+	close(release)
+	deadline = time.Now().Add(time.Second)
+	for len(e.Zombies()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := len(e.Zombies()); n != 0 {
+		t.Fatalf("zombies = %d after return, want 0", n)
+	}
+}
+
+func TestKillWait(t *testing.T) {
+	cases := []struct {
+		grace, write, want time.Duration
+	}{
+		{time.Second, 3 * time.Second, time.Second},
+		{10 * time.Second, 3 * time.Second, 2500 * time.Millisecond},
+		{10 * time.Second, 0, 10 * time.Second},
+		{time.Second, 300 * time.Millisecond, 0},
+		{0, 3 * time.Second, 0},
+	}
+	for _, c := range cases {
+		e := newExecutor(KillGrace(c.grace), ServerTimeout(0, c.write, 0))
+		if got := e.killWait(); got != c.want {
+			t.Errorf("killWait(grace=%v, write=%v) = %v, want %v", c.grace, c.write, got, c.want)
+		}
+	}
+}
+
+// /kill在写超时前返回任务仍在运行
+func TestKillRequestWithinWriteTimeout(t *testing.T) {
+	e := newExecutor(KillGrace(10*time.Second), ServerTimeout(0, time.Second, 0))
+	e.Init(Standalone())
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	e.RegTask("task.block", func(cxt context.Context, param *RunReq) string {
+		close(started)
+		<-release //忽略ctx
+		return ""
+	})
+	e.trigger(&RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.block"}, true)
+	<-started
+	request := httptest.NewRequest(http.MethodPost, "/kill", strings.NewReader(`{"jobId":1}`))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	start := time.Now()
+	e.killTask(recorder, request)
+	if d := time.Since(start); d >= time.Second {
+		t.Fatalf("/kill took %v, want less than the 1s write timeout", d)
+	}
+	if !strings.Contains(recorder.Body.String(), "still running") {
+		t.Fatalf("/kill response = %s, want still running", recorder.Body.String())
+	}
+}
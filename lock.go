@@ -0,0 +1,62 @@
+package xxl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+/**
+分布式锁:故障转移、调度中心重试可能使同一任务在多个执行器上同时运行,
+LockMiddleware按任务ID和分片加锁,锁已被持有时本次调度直接失败。
+内置文件锁用于单机多实例,Redis、etcd等可实现Locker接口
+*/
+
+// ErrLockHeld 锁已被其他实例持有
+var ErrLockHeld = errors.New("xxl: lock is held by another instance")
+
+// Locker 分布式锁
+type Locker interface {
+	// Lock 获取锁,锁已被持有时立即返回ErrLockHeld,不等待;
+	// ctx在任务结束时取消,需要续期的实现可在ctx取消前持续续期
+	Lock(ctx context.Context, key string) (unlock func(), err error)
+}
+
+// LockMiddleware 按任务ID和分片加锁,保证同一时间只有一个实例运行,锁被持有时回调失败
+func LockMiddleware(locker Locker) Middleware {
+	return func(next TaskFunc) TaskFunc {
+		return func(ctx context.Context, param *RunReq) string {
+			key := lockKey(param)
+			unlock, err := locker.Lock(ctx, key)
+			if err == ErrLockHeld {
+				JobLog(ctx, "任务锁已被其他实例持有:%s", key)
+				return Fail(ctx, "job is running on another executor, lock %s held", key)
+			}
+			if err != nil {
+				JobLog(ctx, "任务加锁失败:%s %v", key, err)
+				return Fail(ctx, "job lock %s err:%v", key, err)
+			}
+			defer unlock()
+			return next(ctx, param)
+		}
+	}
+}
+
+// 锁标识 xxl-job-{jobId}-{shardIndex}-{shardTotal}
+func lockKey(param *RunReq) string {
+	return fmt.Sprintf("xxl-job-%d-%d-%d", param.JobID, param.BroadcastIndex, param.BroadcastTotal)
+}
+
+// FileLocker 文件锁,锁文件为 dir/key.lock,适用于同一主机的多个执行器实例
+func FileLocker(dir string) Locker {
+	return &fileLocker{dir: dir}
+}
+
+type fileLocker struct {
+	dir string
+}
+
+func (f *fileLocker) path(key string) string {
+	return filepath.Join(f.dir, key+".lock")
+}
@@ -0,0 +1,29 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly || illumos)
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly,!illumos
+
+package xxl
+
+import (
+	"context"
+	"os"
+)
+
+// 没有flock的平台(windows、solaris、aix、plan9、wasm等)以独占创建锁文件加锁,解锁时删除;
+// 进程异常退出时锁文件残留,需手动删除
+func (f *fileLocker) Lock(ctx context.Context, key string) (func(), error) {
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return nil, err
+	}
+	path := f.path(key)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, ErrLockHeld
+		}
+		return nil, err
+	}
+	_ = file.Close()
+	return func() {
+		_ = os.Remove(path)
+	}, nil
+}
@@ -0,0 +1,32 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly || illumos
+// +build linux darwin freebsd netbsd openbsd dragonfly illumos
+
+package xxl
+
+import (
+	"context"
+	"os"
+	"syscall"
+)
+
+// 使用flock加锁,进程退出时自动释放
+func (f *fileLocker) Lock(ctx context.Context, key string) (func(), error) {
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(f.path(key), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = file.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, ErrLockHeld
+		}
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		_ = file.Close()
+	}, nil
+}
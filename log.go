@@ -3,17 +3,70 @@ package xxl
 import (
 	"fmt"
 	"log"
+	"strings"
 )
 
 // LogFunc 应用日志
 type LogFunc func(req LogReq, res *LogRes) []byte
 
-// Logger 系统日志
+// Logger 系统日志(printf风格,兼容旧版)
 type Logger interface {
 	Info(format string, a ...interface{})
 	Error(format string, a ...interface{})
 }
 
+// Level 日志级别
+type Level int8
+
+// 日志级别
+const (
+	LevelDebug Level = iota - 1
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String 级别名称
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LEVEL(%d)", int8(l))
+}
+
+// 常用日志字段名
+const (
+	FieldJobID     = "job_id"
+	FieldLogID     = "log_id"
+	FieldHandler   = "handler"
+	FieldAdminAddr = "admin_addr"
+	FieldError     = "error"
+)
+
+// Field 日志字段
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F 创建日志字段
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// StructuredLogger 结构化分级日志
+type StructuredLogger interface {
+	Log(level Level, msg string, fields ...Field)
+}
+
+// 默认日志
 type logger struct {
 }
 
@@ -24,3 +77,105 @@ func (l *logger) Info(format string, a ...interface{}) {
 func (l *logger) Error(format string, a ...interface{}) {
 	log.Println(fmt.Sprintf(format, a...))
 }
+
+func (l *logger) Log(level Level, msg string, fields ...Field) {
+	line := "[" + level.String() + "] " + formatFields(msg, fields)
+	if level >= LevelWarn {
+		log.Println(line)
+		return
+	}
+	fmt.Println(line)
+}
+
+// 将printf风格的Logger适配为StructuredLogger
+type printfLogger struct {
+	l Logger
+}
+
+func (p *printfLogger) Log(level Level, msg string, fields ...Field) {
+	line := formatFields(msg, fields)
+	if level >= LevelWarn {
+		p.l.Error("%s", line)
+		return
+	}
+	p.l.Info("%s", line)
+}
+
+// 拼接字段 msg key=value key=value
+func formatFields(msg string, fields []Field) string {
+	if len(fields) == 0 {
+		return msg
+	}
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, f := range fields {
+		b.WriteByte(' ')
+		b.WriteString(f.Key)
+		b.WriteByte('=')
+		v := fmt.Sprint(f.Value)
+		if strings.ContainsAny(v, " \t\n\"=") {
+			v = fmt.Sprintf("%q", v)
+		}
+		b.WriteString(v)
+	}
+	return b.String()
+}
+
+// 执行器内部日志,负责级别过滤和公共字段
+type sysLogger struct {
+	level  Level
+	out    StructuredLogger
+	fields []Field
+}
+
+func newSysLogger(opts Options) *sysLogger {
+	out := opts.sl
+	if out == nil {
+		if sl, ok := opts.l.(StructuredLogger); ok {
+			out = sl
+		} else {
+			out = &printfLogger{l: opts.l}
+		}
+	}
+	return &sysLogger{level: opts.LogLevel, out: out}
+}
+
+// With 附加公共字段
+func (l *sysLogger) With(fields ...Field) *sysLogger {
+	nl := &sysLogger{level: l.level, out: l.out}
+	nl.fields = make([]Field, 0, len(l.fields)+len(fields))
+	nl.fields = append(nl.fields, l.fields...)
+	nl.fields = append(nl.fields, fields...)
+	return nl
+}
+
+// Enabled 是否输出该级别
+func (l *sysLogger) Enabled(level Level) bool {
+	return level >= l.level
+}
+
+func (l *sysLogger) log(level Level, msg string, fields []Field) {
+	if !l.Enabled(level) {
+		return
+	}
+	if len(l.fields) > 0 {
+		all := make([]Field, 0, len(l.fields)+len(fields))
+		all = append(all, l.fields...)
+		fields = append(all, fields...)
+	}
+	l.out.Log(level, msg, fields...)
+}
+
+func (l *sysLogger) Debug(msg string, fields ...Field) { l.log(LevelDebug, msg, fields) }
+func (l *sysLogger) Info(msg string, fields ...Field)  { l.log(LevelInfo, msg, fields) }
+func (l *sysLogger) Warn(msg string, fields ...Field)  { l.log(LevelWarn, msg, fields) }
+func (l *sysLogger) Error(msg string, fields ...Field) { l.log(LevelError, msg, fields) }
+
+// 任务相关字段
+func runFields(param *RunReq) []Field {
+	return []Field{
+		F(FieldJobID, param.JobID),
+		F(FieldLogID, param.LogID),
+		F(FieldHandler, param.ExecutorHandler),
+	}
+}
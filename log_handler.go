@@ -29,6 +29,12 @@ func reqErrLogHandler(w http.ResponseWriter, req *LogReq, err error) {
 		LogContent:  err.Error(),
 		IsEnd:       true,
 	}}
+	status := http.StatusBadRequest
+	if re, ok := err.(*RequestError); ok {
+		status = re.Status
+	}
 	str, _ := json.Marshal(res)
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(status)
 	_, _ = w.Write(str)
 }
@@ -0,0 +1,44 @@
+//go:build go1.21
+// +build go1.21
+
+package xxl
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SlogLogger 将log/slog的Handler适配为StructuredLogger
+func SlogLogger(h slog.Handler) StructuredLogger {
+	return &slogLogger{h: h}
+}
+
+type slogLogger struct {
+	h slog.Handler
+}
+
+func (s *slogLogger) Log(level Level, msg string, fields ...Field) {
+	ctx := context.Background()
+	lvl := slogLevel(level)
+	if !s.h.Enabled(ctx, lvl) {
+		return
+	}
+	r := slog.NewRecord(time.Now(), lvl, msg, 0)
+	for _, f := range fields {
+		r.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	_ = s.h.Handle(ctx, r)
+}
+
+func slogLevel(level Level) slog.Level {
+	switch level {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
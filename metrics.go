@@ -0,0 +1,66 @@
+package xxl
+
+import "sync/atomic"
+
+// Metrics 执行器指标
+type Metrics struct {
+	Running    int   `json:"running"`    //运行列表中的任务数,包含排队和限流等待中尚未开始的
+	Zombies    int   `json:"zombies"`    //已终止但任务函数仍未返回的任务数
+	Queued     int   `json:"queued"`     //并发已满排队等待的任务数,同时计入Running
+	Triggered  int64 `json:"triggered"`  //累计接受的调度数,包含排队、限流等待及开始前被终止的
+	Rejected   int64 `json:"rejected"`   //累计被拒绝的调度数
+	Duplicated int64 `json:"duplicated"` //累计忽略的重复调度数
+	Limited    int64 `json:"limited"`    //累计因限流被拒绝的调度数
+	Delayed    int64 `json:"delayed"`    //累计因限流延迟执行的调度数
+	Overloaded int64 `json:"overloaded"` //累计因资源不足被拒绝的调度数
+	Succeeded  int64 `json:"succeeded"`  //累计执行成功数
+	Failed     int64 `json:"failed"`     //累计执行失败数
+	Killed     int64 `json:"killed"`     //累计终止数
+	Reporting  int   `json:"reporting"`  //运行中且上报过进度的任务数
+	Progress   int64 `json:"progress"`   //累计进度上报次数
+}
+
+// 累计计数
+type counters struct {
+	triggered  int64
+	rejected   int64
+	duplicated int64
+	limited    int64
+	delayed    int64
+	overloaded int64
+	succeeded  int64
+	failed     int64
+	killed     int64
+	progress   int64
+}
+
+func (c *counters) add(n *int64) {
+	atomic.AddInt64(n, 1)
+}
+
+// Metrics 执行器指标
+func (e *executor) Metrics() Metrics {
+	c := &e.counters
+	reporting := 0
+	for _, t := range e.runList.GetAll() {
+		if t.lastProgress() != nil {
+			reporting++
+		}
+	}
+	return Metrics{
+		Running:    e.runList.Len(),
+		Zombies:    e.zombieList.Len(),
+		Queued:     e.pool.queued(),
+		Triggered:  atomic.LoadInt64(&c.triggered),
+		Rejected:   atomic.LoadInt64(&c.rejected),
+		Duplicated: atomic.LoadInt64(&c.duplicated),
+		Limited:    atomic.LoadInt64(&c.limited),
+		Delayed:    atomic.LoadInt64(&c.delayed),
+		Overloaded: atomic.LoadInt64(&c.overloaded),
+		Succeeded:  atomic.LoadInt64(&c.succeeded),
+		Failed:     atomic.LoadInt64(&c.failed),
+		Killed:     atomic.LoadInt64(&c.killed),
+		Reporting:  reporting,
+		Progress:   atomic.LoadInt64(&c.progress),
+	}
+}
@@ -1,11 +1,32 @@
 package xxl
 
+import (
+	"errors"
+	"fmt"
+)
+
 // Middleware 中间件构造函数
 type Middleware func(TaskFunc) TaskFunc
 
-func (e *executor) chain(next TaskFunc) TaskFunc {
+func (e *executor) chain(next TaskFunc) (TaskFunc, error) {
 	for i := range e.middlewares {
-		next = e.middlewares[len(e.middlewares)-1-i](next)
+		var err error
+		if next, err = wrap(e.middlewares[len(e.middlewares)-1-i], next); err != nil {
+			return nil, err
+		}
+	}
+	return next, nil
+}
+
+// 应用中间件,构造时panic或返回nil时返回错误,不能跳过中间件(如加锁中间件)继续执行
+func wrap(m Middleware, next TaskFunc) (fn TaskFunc, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			fn, err = nil, fmt.Errorf("middleware panic: %v", r)
+		}
+	}()
+	if fn = m(next); fn == nil {
+		return nil, errors.New("middleware returned nil")
 	}
-	return next
+	return fn, nil
 }
@@ -0,0 +1,65 @@
+package xxl
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// 中间件构造失败时不注册任务,不能跳过中间件继续执行
+func TestRegTaskMiddlewareFails(t *testing.T) {
+	cases := []struct {
+		name string
+		m    Middleware
+	}{
+		{"panic", func(next TaskFunc) TaskFunc { panic("lock unavailable") }},
+		{"nil", func(next TaskFunc) TaskFunc { return nil }},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e := newExecutor()
+			e.Init(Standalone())
+			e.Use(c.m)
+			ran := false
+			e.RegTask("task.test", func(cxt context.Context, param *RunReq) string {
+				ran = true
+				return ""
+			})
+			if list := e.ListTask(); len(list) != 0 {
+				t.Fatalf("ListTask() = %v, want empty", list)
+			}
+			msg, ok := e.trigger(&RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.test"}, true)
+			if ok || !strings.Contains(msg, "not registered") {
+				t.Fatalf("trigger() = %q, %v, want rejected", msg, ok)
+			}
+			if ran {
+				t.Fatal("task ran without middleware")
+			}
+		})
+	}
+}
+
+// 中间件按注册顺序由外到内执行
+func TestMiddlewareOrder(t *testing.T) {
+	e := newExecutor()
+	e.Init(Standalone())
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next TaskFunc) TaskFunc {
+			return func(cxt context.Context, param *RunReq) string {
+				order = append(order, name)
+				return next(cxt, param)
+			}
+		}
+	}
+	e.Use(mark("a"), mark("b"))
+	e.RegTask("task.test", func(cxt context.Context, param *RunReq) string {
+		order = append(order, "task")
+		return ""
+	})
+	fn := e.regList.Get("task.test").fn
+	fn(context.Background(), &RunReq{})
+	if got := strings.Join(order, ","); got != "a,b,task" {
+		t.Fatalf("order = %s, want a,b,task", got)
+	}
+}
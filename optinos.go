@@ -1,7 +1,10 @@
 package xxl
 
 import (
+	"crypto/tls"
 	"github.com/go-basic/ipv4"
+	"net"
+	"net/http"
 	"time"
 )
 
@@ -13,15 +16,83 @@ type Options struct {
 	ExecutorPort string        `json:"executor_port"` //本地(执行器)端口
 	RegistryKey  string        `json:"registry_key"`  //执行器名称
 	LogDir       string        `json:"log_dir"`       //日志目录
+	LogLevel     Level         `json:"log_level"`     //系统日志最低级别
+	AdminVersion string        `json:"admin_version"` //调度中心版本,默认兼容模式
 
-	l Logger //日志处理
+	ListenIp     string        `json:"listen_ip"`     //监听IP,默认监听所有网卡
+	BasePath     string        `json:"base_path"`     //路由前缀,如 /xxl-job
+	ReadTimeout  time.Duration `json:"read_timeout"`  //服务读超时
+	WriteTimeout time.Duration `json:"write_timeout"` //服务写超时
+	IdleTimeout  time.Duration `json:"idle_timeout"`  //服务空闲连接超时
+	TLSCertFile  string        `json:"tls_cert_file"` //TLS证书文件
+	TLSKeyFile   string        `json:"tls_key_file"`  //TLS私钥文件
+
+	MaxRequestBody        int64 `json:"max_request_body"`        //请求体大小限制(字节),0为不限制
+	DisallowUnknownFields bool  `json:"disallow_unknown_fields"` //请求JSON包含未知字段时拒绝
+
+	KillGrace      time.Duration `json:"kill_grace"`       //终止任务后等待其停止的时间,0为不等待
+	RejectZombie   bool          `json:"reject_zombie"`    //同一任务有已终止但未退出的实例时拒绝新调度
+	HistorySize    int           `json:"history_size"`     //保留的最近完成记录数
+	DebugEndpoint  bool          `json:"debug_endpoint"`   //开启调试接口 /debug/runs
+	PanicStackSize int           `json:"panic_stack_size"` //panic堆栈写入回调信息的最大字节数,0为不写入
+	Standalone     bool          `json:"standalone"`       //独立模式,不注册也不回调调度中心,配合本地调度使用
+	DedupWindow    time.Duration `json:"dedup_window"`     //重复调度(相同LogID)的识别时间,默认0不去重
+	MaxConcurrency int           `json:"max_concurrency"`  //同时执行的最大任务数,超出时排队,0为不限制
+	PriorityAging  time.Duration `json:"priority_aging"`   //排队任务每等待该时长优先级加1,默认10秒,0为不提升
+
+	MaxMemoryPercent float64       `json:"max_memory_percent"` //内存使用率超过该值时拒绝调度,0为不限制
+	MaxCPUPercent    float64       `json:"max_cpu_percent"`    //CPU使用率超过该值时拒绝调度,0为不限制
+	ResourceInterval time.Duration `json:"resource_interval"`  //资源采集间隔,默认1秒
+
+	ChildTriggerPath string `json:"child_trigger_path"` //调度中心触发子任务接口路径,为空时不支持子任务
+
+	panicHandler PanicHandler //任务panic处理
+
+	AdvertiseAddr string `json:"advertise_addr"` //注册到调度中心的完整地址,如 http://10.0.0.1:8080/xxl-job
+	AdvertiseHost string `json:"advertise_host"` //注册到调度中心的主机,默认为ExecutorIp
+	AdvertisePort string `json:"advertise_port"` //注册到调度中心的端口,默认为ExecutorPort
+	Interface     string `json:"interface"`      //按网卡名称选择ExecutorIp
+	CIDR          string `json:"cidr"`           //按网段选择ExecutorIp
+	IPv6          bool   `json:"ipv6"`           //选择ExecutorIp时优先IPv6
+
+	RegistryInterval   time.Duration `json:"registry_interval"`    //注册心跳间隔
+	RegistryBackoffMin time.Duration `json:"registry_backoff_min"` //注册失败重试初始间隔
+	RegistryBackoffMax time.Duration `json:"registry_backoff_max"` //注册失败重试最大间隔,默认为注册心跳间隔
+
+	onRegistrySuccess func(addr string)                          //注册成功回调
+	onRegistryFailure func(addr string, err error, failures int) //注册失败回调
+	registrars        []Registrar                                //注册目标,默认注册到调度中心
+
+	checkpoint CheckpointStore //断点存储,默认使用LogDir下的文件存储
+
+	tlsConfig *tls.Config  //TLS配置
+	listener  net.Listener //外部传入的监听器
+
+	client    *http.Client      //调度中心请求客户端
+	transport http.RoundTripper //调度中心请求Transport
+	header    http.Header       //调度中心请求自定义header
+	signers   []RequestSigner   //调度中心请求签名
+
+	l  Logger           //日志处理
+	sl StructuredLogger //结构化日志处理
 }
 
 func newOptions(opts ...Option) Options {
 	opt := Options{
+		Timeout:      DefaultTimeout,
 		ExecutorIp:   ipv4.LocalIP(),
 		ExecutorPort: DefaultExecutorPort,
 		RegistryKey:  DefaultRegistryKey,
+		WriteTimeout: DefaultWriteTimeout,
+
+		MaxRequestBody: DefaultMaxRequestBody,
+		HistorySize:    DefaultHistorySize,
+		PriorityAging:  DefaultPriorityAging,
+
+		ResourceInterval: DefaultResourceInterval,
+
+		RegistryInterval:   DefaultRegistryInterval,
+		RegistryBackoffMin: DefaultRegistryBackoff,
 	}
 
 	for _, o := range opts {
@@ -40,6 +111,17 @@ type Option func(o *Options)
 var (
 	DefaultExecutorPort = "9999"
 	DefaultRegistryKey  = "golang-jobs"
+	DefaultWriteTimeout = time.Second * 3
+	DefaultTimeout      = time.Second * 5
+
+	DefaultMaxRequestBody int64 = 4 << 20
+	DefaultHistorySize          = 100
+	DefaultPriorityAging        = time.Second * 10
+
+	DefaultResourceInterval = time.Second
+
+	DefaultRegistryInterval = time.Second * 20
+	DefaultRegistryBackoff  = time.Second
 )
 
 // ServerAddr 设置调度中心地址
@@ -83,3 +165,311 @@ func SetLogger(l Logger) Option {
 		o.l = l
 	}
 }
+
+// SetStructuredLogger 设置结构化日志处理器,优先于SetLogger
+func SetStructuredLogger(l StructuredLogger) Option {
+	return func(o *Options) {
+		o.sl = l
+	}
+}
+
+// SetLogLevel 设置系统日志最低级别,心跳等日志为Debug级别
+func SetLogLevel(level Level) Option {
+	return func(o *Options) {
+		o.LogLevel = level
+	}
+}
+
+// ListenIp 设置监听IP,用于绑定指定网卡
+func ListenIp(ip string) Option {
+	return func(o *Options) {
+		o.ListenIp = ip
+	}
+}
+
+// Listener 使用外部监听器(如Unix socket),设置后忽略ListenIp和ExecutorPort的监听
+func Listener(l net.Listener) Option {
+	return func(o *Options) {
+		o.listener = l
+	}
+}
+
+// BasePath 设置路由前缀,/run、/kill等挂载在该前缀下
+func BasePath(path string) Option {
+	return func(o *Options) {
+		o.BasePath = path
+	}
+}
+
+// ServerTimeout 设置服务读、写、空闲超时,为0时不限制
+func ServerTimeout(read, write, idle time.Duration) Option {
+	return func(o *Options) {
+		o.ReadTimeout = read
+		o.WriteTimeout = write
+		o.IdleTimeout = idle
+	}
+}
+
+// TLS 启用HTTPS,注册地址使用https://
+func TLS(certFile, keyFile string) Option {
+	return func(o *Options) {
+		o.TLSCertFile = certFile
+		o.TLSKeyFile = keyFile
+	}
+}
+
+// TLSConfig 设置TLS配置,证书可直接放在Certificates中
+func TLSConfig(c *tls.Config) Option {
+	return func(o *Options) {
+		o.tlsConfig = c
+	}
+}
+
+// RequestSigner 调度中心请求签名,在请求发出前调用,返回错误时放弃请求
+type RequestSigner func(request *http.Request) error
+
+// Timeout 设置调度中心接口超时时间,使用HttpClient时只用于停止时摘除注册的超时
+func Timeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = timeout
+	}
+}
+
+// HttpClient 设置调度中心请求客户端(代理、mTLS、连接池、测试替身等)
+func HttpClient(client *http.Client) Option {
+	return func(o *Options) {
+		o.client = client
+	}
+}
+
+// Transport 设置调度中心请求Transport,使用HttpClient时不生效
+func Transport(rt http.RoundTripper) Option {
+	return func(o *Options) {
+		o.transport = rt
+	}
+}
+
+// Header 设置调度中心请求自定义header
+func Header(key, value string) Option {
+	return func(o *Options) {
+		if o.header == nil {
+			o.header = make(http.Header)
+		}
+		o.header.Add(key, value)
+	}
+}
+
+// Signer 添加调度中心请求签名
+func Signer(signers ...RequestSigner) Option {
+	return func(o *Options) {
+		o.signers = append(o.signers, signers...)
+	}
+}
+
+// AdvertiseHost 设置注册到调度中心的主机(IP或域名),用于容器、NAT等监听地址与访问地址不同的场景
+func AdvertiseHost(host string) Option {
+	return func(o *Options) {
+		o.AdvertiseHost = host
+	}
+}
+
+// AdvertisePort 设置注册到调度中心的端口,如容器端口映射后的宿主机端口
+func AdvertisePort(port string) Option {
+	return func(o *Options) {
+		o.AdvertisePort = port
+	}
+}
+
+// ExecutorInterface 按网卡名称选择执行器IP,优先于ExecutorIp
+func ExecutorInterface(name string) Option {
+	return func(o *Options) {
+		o.Interface = name
+	}
+}
+
+// ExecutorCIDR 按网段选择执行器IP,如 10.0.0.0/8,优先于ExecutorIp
+func ExecutorCIDR(cidr string) Option {
+	return func(o *Options) {
+		o.CIDR = cidr
+	}
+}
+
+// PreferIPv6 选择执行器IP时优先IPv6
+func PreferIPv6() Option {
+	return func(o *Options) {
+		o.IPv6 = true
+	}
+}
+
+// AdvertiseAddr 设置注册到调度中心的完整地址,
+// 执行器挂载在宿主服务(Handler)上时设置为宿主服务的对外地址,包含路由前缀
+func AdvertiseAddr(addr string) Option {
+	return func(o *Options) {
+		o.AdvertiseAddr = addr
+	}
+}
+
+// RegistryInterval 设置注册心跳间隔,默认20秒,非正数时使用默认值
+func RegistryInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.RegistryInterval = interval
+	}
+}
+
+// RegistryBackoff 设置注册失败后指数退避的初始与最大间隔,初始间隔非正数时使用默认值1秒
+func RegistryBackoff(min, max time.Duration) Option {
+	return func(o *Options) {
+		o.RegistryBackoffMin = min
+		o.RegistryBackoffMax = max
+	}
+}
+
+// OnRegistrySuccess 注册成功回调,在注册循环中调用,panic会被恢复
+func OnRegistrySuccess(fn func(addr string)) Option {
+	return func(o *Options) {
+		o.onRegistrySuccess = fn
+	}
+}
+
+// OnRegistryFailure 注册失败回调,failures为该调度中心的连续失败次数,panic会被恢复
+func OnRegistryFailure(fn func(addr string, err error, failures int)) Option {
+	return func(o *Options) {
+		o.onRegistryFailure = fn
+	}
+}
+
+// SetRegistrar 设置注册目标,替换默认的调度中心注册
+func SetRegistrar(registrars ...Registrar) Option {
+	return func(o *Options) {
+		o.registrars = registrars
+	}
+}
+
+// ChildTriggerPath 设置调度中心触发子任务接口路径并开启子任务,官方调度中心没有该接口,需自行扩展
+func ChildTriggerPath(path string) Option {
+	return func(o *Options) {
+		o.ChildTriggerPath = path
+	}
+}
+
+// SetCheckpointStore 设置断点存储,默认在设置LogDir时使用 LogDir/checkpoint 下的文件存储
+func SetCheckpointStore(store CheckpointStore) Option {
+	return func(o *Options) {
+		o.checkpoint = store
+	}
+}
+
+// DedupWindow 开启重复调度去重,识别时间内相同LogID的调度直接返回成功,不再执行,建议大于调度中心的重试间隔,默认0不去重
+func DedupWindow(d time.Duration) Option {
+	return func(o *Options) {
+		o.DedupWindow = d
+	}
+}
+
+// MaxConcurrency 设置同时执行的最大任务数,超出的调度按优先级(Priority)排队,0为不限制
+func MaxConcurrency(n int) Option {
+	return func(o *Options) {
+		o.MaxConcurrency = n
+	}
+}
+
+// PriorityAging 设置排队任务优先级提升间隔,每等待该时长优先级加1,防止低优先级任务饿死
+func PriorityAging(d time.Duration) Option {
+	return func(o *Options) {
+		o.PriorityAging = d
+	}
+}
+
+// ResourceGuard 资源保护,内存或CPU使用率(0~100)超过阈值时拒绝调度、忙碌检测返回忙碌,0为不限制;
+// Linux下优先读取cgroup(容器)的使用情况,其他平台不支持
+func ResourceGuard(maxMemoryPercent, maxCPUPercent float64) Option {
+	return func(o *Options) {
+		o.MaxMemoryPercent = maxMemoryPercent
+		o.MaxCPUPercent = maxCPUPercent
+	}
+}
+
+// ResourceInterval 设置资源采集间隔,CPU使用率为该间隔内的平均值
+func ResourceInterval(d time.Duration) Option {
+	return func(o *Options) {
+		o.ResourceInterval = d
+	}
+}
+
+// Standalone 独立模式,不连接调度中心,任务通过Schedule本地调度
+func Standalone() Option {
+	return func(o *Options) {
+		o.Standalone = true
+	}
+}
+
+// AdminVersion 设置调度中心版本(AdminVersion22~AdminVersion24,AdminVersion21等同于AdminVersion22),按版本编码回调参数,默认兼容模式
+func AdminVersion(version string) Option {
+	return func(o *Options) {
+		o.AdminVersion = version
+	}
+}
+
+// MaxRequestBody 设置请求体大小限制(字节),默认4MB,0为不限制
+func MaxRequestBody(n int64) Option {
+	return func(o *Options) {
+		o.MaxRequestBody = n
+	}
+}
+
+// DisallowUnknownFields 请求JSON包含未知字段时拒绝,默认忽略未知字段以兼容新版调度中心
+func DisallowUnknownFields() Option {
+	return func(o *Options) {
+		o.DisallowUnknownFields = true
+	}
+}
+
+// KillGrace 设置终止任务后等待其停止的时间,超时未停止时/kill返回失败;
+// /kill请求中的等待不超过服务写超时(WriteTimeout)减500毫秒,否则调度中心收不到结果,调度中心请求超时也需大于该时间
+func KillGrace(d time.Duration) Option {
+	return func(o *Options) {
+		o.KillGrace = d
+	}
+}
+
+// RejectZombie 同一任务有已终止但任务函数仍未返回的实例时,拒绝新的调度
+func RejectZombie() Option {
+	return func(o *Options) {
+		o.RejectZombie = true
+	}
+}
+
+// HistorySize 设置保留的最近完成记录数,默认100,0为不保留
+func HistorySize(n int) Option {
+	return func(o *Options) {
+		o.HistorySize = n
+	}
+}
+
+// DebugEndpoint 开启调试接口 GET /debug/runs,返回运行中任务、最近完成记录、指标和注册状态
+func DebugEndpoint() Option {
+	return func(o *Options) {
+		o.DebugEndpoint = true
+	}
+}
+
+// SetPanicHandler 设置任务panic处理函数,如上报告警
+func SetPanicHandler(h PanicHandler) Option {
+	return func(o *Options) {
+		o.panicHandler = h
+	}
+}
+
+// PanicStackSize 设置panic堆栈写入回调信息(HandleMsg)的最大字节数,默认0不写入
+func PanicStackSize(n int) Option {
+	return func(o *Options) {
+		o.PanicStackSize = n
+	}
+}
+
+// LogDir 设置任务日志目录,设置后每次调度写入 LogDir/yyyy-MM-dd/logId.log,并默认从该目录查询日志
+func LogDir(dir string) Option {
+	return func(o *Options) {
+		o.LogDir = dir
+	}
+}
@@ -0,0 +1,40 @@
+package xxl
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicHandler 任务panic处理函数,stack为panic时的堆栈
+type PanicHandler func(ctx context.Context, param *RunReq, err interface{}, stack []byte)
+
+// 任务panic:记录系统日志和任务日志,派发事件,调用PanicHandler,返回回调信息
+func (e *executor) handlePanic(t *Task, err interface{}, stack []byte) string {
+	t.log.Error("任务panic", F("panic", err), F("stack", string(stack)))
+	t.jlog.Write("任务panic: %v\n%s", err, stack)
+
+	ev := runEvent(EventTaskPanic, t.Param)
+	ev.Code, ev.Msg = FailureCode, fmt.Sprint(err)
+	e.emit(ev)
+
+	if e.opts.panicHandler != nil {
+		func() {
+			defer func() {
+				if perr := recover(); perr != nil {
+					e.log.Error("PanicHandler panic", append(runFields(t.Param), F("panic", perr), F("stack", string(debug.Stack())))...)
+				}
+			}()
+			e.opts.panicHandler(t.Ext, t.Param, err, stack)
+		}()
+	}
+
+	msg := fmt.Sprintf("task panic:%v", err)
+	if n := e.opts.PanicStackSize; n > 0 {
+		if len(stack) > n {
+			stack = append(stack[:n:n], "..."...)
+		}
+		msg += "\n" + string(stack)
+	}
+	return msg
+}
@@ -0,0 +1,353 @@
+package xxl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParamFormat 任务参数格式
+type ParamFormat int
+
+// 任务参数格式
+const (
+	ParamJSON  ParamFormat = iota // {"date":"2021-01-01","limit":100}
+	ParamKV                       // date=2021-01-01,limit=100 以逗号、分号或换行分隔,切片值以|分隔
+	ParamQuery                    // date=2021-01-01&limit=100 切片使用重复key
+)
+
+/**
+参数绑定:字段名取 param 标签,其次 json 标签,最后为字段名(不区分大小写)
+  default:"100"                默认值
+  validate:"required,min=1"    校验 required、min、max(数值比较大小,字符串和切片比较长度)、oneof=a b c
+*/
+
+// BindParams 在执行任务前将ExecutorParams解析到proto类型的新实例中,
+// 解析或校验失败时任务直接回调失败,不执行handler;任务中通过BoundParams获取。
+// proto不是结构体、默认值或校验规则错误时拒绝注册任务
+func BindParams(proto interface{}, format ParamFormat) TaskOption {
+	typ := reflect.TypeOf(proto)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	err := checkParamsType(typ)
+	return func(o *taskOptions) {
+		o.paramType = typ
+		o.paramFormat = format
+		if err != nil {
+			o.err = err
+		}
+	}
+}
+
+// 检查参数类型、默认值和校验规则
+func checkParamsType(typ reflect.Type) error {
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return fmt.Errorf("params type must be struct, got %v", typ)
+	}
+	v := reflect.New(typ).Elem()
+	if err := applyDefaults(v); err != nil {
+		return err
+	}
+	return eachField(v, func(f reflect.StructField, fv reflect.Value) error {
+		for _, rule := range strings.Split(f.Tag.Get("validate"), ",") {
+			if err := checkRuleSyntax(fv, strings.TrimSpace(rule)); err != nil {
+				return fmt.Errorf("param %s %v", paramName(f), err)
+			}
+		}
+		return nil
+	})
+}
+
+// 检查校验规则是否可用于该字段
+func checkRuleSyntax(fv reflect.Value, rule string) error {
+	name, arg := splitRule(rule)
+	switch name {
+	case "", "required":
+	case "min", "max":
+		if _, err := strconv.ParseFloat(arg, 64); err != nil {
+			return fmt.Errorf("invalid rule %q", rule)
+		}
+		if _, ok := measure(fv); !ok {
+			return fmt.Errorf("rule %q not supported for %s", rule, fv.Type())
+		}
+	case "oneof":
+		if len(strings.Fields(arg)) == 0 {
+			return fmt.Errorf("invalid rule %q", rule)
+		}
+	default:
+		return fmt.Errorf("unknown rule %q", rule)
+	}
+	return nil
+}
+
+// 规则名和参数
+func splitRule(rule string) (name, arg string) {
+	if i := strings.Index(rule, "="); i >= 0 {
+		return rule[:i], rule[i+1:]
+	}
+	return rule, ""
+}
+
+// BoundParams 获取BindParams解析的参数,类型为proto的指针
+func BoundParams(ctx context.Context) interface{} {
+	if t := taskFrom(ctx); t != nil {
+		return t.params
+	}
+	return nil
+}
+
+// 解析并校验参数
+func bindParams(typ reflect.Type, format ParamFormat, raw string) (interface{}, error) {
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("params type must be struct, got %v", typ)
+	}
+	ptr := reflect.New(typ)
+	v := ptr.Elem()
+	if err := applyDefaults(v); err != nil {
+		return nil, err
+	}
+	raw = strings.TrimSpace(raw)
+	if raw != "" {
+		var err error
+		switch format {
+		case ParamJSON:
+			err = json.Unmarshal([]byte(raw), ptr.Interface())
+		case ParamKV:
+			err = decodeValues(v, parseKV(raw))
+		case ParamQuery:
+			var values url.Values
+			if values, err = url.ParseQuery(raw); err == nil {
+				err = decodeValues(v, values)
+			}
+		default:
+			err = fmt.Errorf("unknown params format %d", format)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := validateParams(v); err != nil {
+		return nil, err
+	}
+	return ptr.Interface(), nil
+}
+
+// key=value 以逗号、分号或换行分隔
+func parseKV(raw string) url.Values {
+	values := url.Values{}
+	fields := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == ';' || r == '\n' || r == '\r'
+	})
+	for _, kv := range fields {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			values.Add(kv, "")
+			continue
+		}
+		key := strings.TrimSpace(kv[:i])
+		for _, s := range strings.Split(strings.TrimSpace(kv[i+1:]), "|") {
+			values.Add(key, s)
+		}
+	}
+	return values
+}
+
+// 参数字段名
+func paramName(f reflect.StructField) string {
+	if name := f.Tag.Get("param"); name != "" {
+		return name
+	}
+	if name := strings.Split(f.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	return f.Name
+}
+
+// 遍历可导出字段
+func eachField(v reflect.Value, fn func(f reflect.StructField, fv reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		if err := fn(f, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyDefaults(v reflect.Value) error {
+	return eachField(v, func(f reflect.StructField, fv reflect.Value) error {
+		def, ok := f.Tag.Lookup("default")
+		if !ok {
+			return nil
+		}
+		if err := setValue(fv, strings.Split(def, "|")); err != nil {
+			return fmt.Errorf("param %s default: %v", paramName(f), err)
+		}
+		return nil
+	})
+}
+
+func decodeValues(v reflect.Value, values url.Values) error {
+	lower := make(map[string][]string, len(values))
+	for k, vs := range values {
+		lower[strings.ToLower(k)] = vs
+	}
+	return eachField(v, func(f reflect.StructField, fv reflect.Value) error {
+		name := paramName(f)
+		vs, ok := values[name]
+		if !ok {
+			if vs, ok = lower[strings.ToLower(name)]; !ok {
+				return nil
+			}
+		}
+		if err := setValue(fv, vs); err != nil {
+			return fmt.Errorf("param %s: %v", name, err)
+		}
+		return nil
+	})
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// 设置字段值,切片使用全部值,其他类型使用第一个值
+func setValue(fv reflect.Value, vs []string) error {
+	if fv.Kind() == reflect.Slice {
+		s := reflect.MakeSlice(fv.Type(), len(vs), len(vs))
+		for i, str := range vs {
+			if err := setScalar(s.Index(i), str); err != nil {
+				return err
+			}
+		}
+		fv.Set(s)
+		return nil
+	}
+	if len(vs) == 0 {
+		return nil
+	}
+	return setScalar(fv, vs[0])
+}
+
+func setScalar(fv reflect.Value, s string) error {
+	s = strings.TrimSpace(s)
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
+
+func validateParams(v reflect.Value) error {
+	return eachField(v, func(f reflect.StructField, fv reflect.Value) error {
+		rules := f.Tag.Get("validate")
+		if rules == "" {
+			return nil
+		}
+		for _, rule := range strings.Split(rules, ",") {
+			if err := checkRule(fv, strings.TrimSpace(rule)); err != nil {
+				return fmt.Errorf("param %s %v", paramName(f), err)
+			}
+		}
+		return nil
+	})
+}
+
+func checkRule(fv reflect.Value, rule string) error {
+	name, arg := splitRule(rule)
+	switch name {
+	case "":
+		return nil
+	case "required":
+		if fv.IsZero() {
+			return fmt.Errorf("is required")
+		}
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Errorf("invalid rule %q", rule)
+		}
+		n, ok := measure(fv)
+		if !ok {
+			return fmt.Errorf("rule %q not supported for %s", rule, fv.Type())
+		}
+		if name == "min" && n < limit {
+			return fmt.Errorf("must be at least %s", arg)
+		}
+		if name == "max" && n > limit {
+			return fmt.Errorf("must be at most %s", arg)
+		}
+	case "oneof":
+		s := fmt.Sprint(fv.Interface())
+		for _, opt := range strings.Fields(arg) {
+			if s == opt {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of [%s]", arg)
+	default:
+		return fmt.Errorf("unknown rule %q", rule)
+	}
+	return nil
+}
+
+// 数值取值,字符串和切片取长度
+func measure(fv reflect.Value) (float64, bool) {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), true
+	case reflect.String, reflect.Slice, reflect.Map:
+		return float64(fv.Len()), true
+	}
+	return 0, false
+}
@@ -0,0 +1,154 @@
+package xxl
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testParams struct {
+	Date     string        `param:"date" validate:"required"`
+	Limit    int           `json:"limit" default:"100" validate:"min=1,max=1000"`
+	Mode     string        `default:"full" validate:"oneof=full incr"`
+	IDs      []int64       `param:"ids" validate:"max=3"`
+	Timeout  time.Duration `param:"timeout" default:"30s"`
+	Dry      bool          `param:"dry"`
+	internal string
+}
+
+var paramsType = reflect.TypeOf(testParams{})
+
+func TestBindParams(t *testing.T) {
+	cases := []struct {
+		name   string
+		format ParamFormat
+		raw    string
+		want   testParams
+	}{
+		{"defaults", ParamKV, "date=2021-01-01",
+			testParams{Date: "2021-01-01", Limit: 100, Mode: "full", Timeout: 30 * time.Second}},
+		{"kv", ParamKV, "date=2021-01-01, limit=5;mode=incr\nids=1|2|3\ntimeout=1m",
+			testParams{Date: "2021-01-01", Limit: 5, Mode: "incr", IDs: []int64{1, 2, 3}, Timeout: time.Minute}},
+		{"kv case insensitive", ParamKV, "DATE=2021-01-01,Limit=7,dry=true",
+			testParams{Date: "2021-01-01", Limit: 7, Mode: "full", Timeout: 30 * time.Second, Dry: true}},
+		{"query", ParamQuery, "date=2021-01-01&limit=10&ids=4&ids=5&dry=1",
+			testParams{Date: "2021-01-01", Limit: 10, Mode: "full", IDs: []int64{4, 5}, Timeout: 30 * time.Second, Dry: true}},
+		{"json", ParamJSON, `{"Date":"2021-01-01","limit":20,"IDs":[6]}`,
+			testParams{Date: "2021-01-01", Limit: 20, Mode: "full", IDs: []int64{6}, Timeout: 30 * time.Second}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := bindParams(paramsType, c.format, c.raw)
+			if err != nil {
+				t.Fatalf("bindParams(%q) error: %v", c.raw, err)
+			}
+			if !reflect.DeepEqual(*got.(*testParams), c.want) {
+				t.Fatalf("bindParams(%q) = %+v, want %+v", c.raw, *got.(*testParams), c.want)
+			}
+		})
+	}
+}
+
+func TestBindParamsErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		format ParamFormat
+		raw    string
+		err    string
+	}{
+		{"required", ParamKV, "", "param date is required"},
+		{"min", ParamKV, "date=d,limit=0", "param limit must be at least 1"},
+		{"max", ParamKV, "date=d,limit=1001", "param limit must be at most 1000"},
+		{"slice max", ParamKV, "date=d,ids=1|2|3|4", "param ids must be at most 3"},
+		{"oneof", ParamQuery, "date=d&Mode=part", "param Mode must be one of [full incr]"},
+		{"int", ParamKV, "date=d,limit=abc", "param limit"},
+		{"duration", ParamKV, "date=d,timeout=10", "param timeout"},
+		{"json", ParamJSON, "{", "unexpected end of JSON input"},
+		{"format", ParamFormat(9), "date=d", "unknown params format 9"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := bindParams(paramsType, c.format, c.raw)
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Fatalf("bindParams(%q) error = %v, want %q", c.raw, err, c.err)
+			}
+		})
+	}
+}
+
+func TestCheckRuleUnknown(t *testing.T) {
+	type params struct {
+		Name string `validate:"email"`
+	}
+	_, err := bindParams(reflect.TypeOf(params{}), ParamKV, "Name=a")
+	if err == nil || !strings.Contains(err.Error(), `unknown rule "email"`) {
+		t.Fatalf("error = %v, want unknown rule", err)
+	}
+}
+
+// 参数类型错误在注册时检查,不注册任务
+func TestBindParamsRegister(t *testing.T) {
+	type badDefault struct {
+		Limit int `default:"abc"`
+	}
+	cases := []struct {
+		name  string
+		proto interface{}
+		ok    bool
+	}{
+		{"struct", testParams{}, true},
+		{"pointer", &testParams{}, true},
+		{"map", map[string]string{}, false},
+		{"nil", nil, false},
+		{"bad default", badDefault{}, false},
+		{"unknown rule", struct {
+			Name string `validate:"requird"`
+		}{}, false},
+		{"bad min", struct {
+			Limit int `validate:"min=x"`
+		}{}, false},
+		{"min on bool", struct {
+			Dry bool `validate:"min=1"`
+		}{}, false},
+		{"empty oneof", struct {
+			Mode string `validate:"oneof="`
+		}{}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e := newExecutor()
+			e.Init(Standalone())
+			e.RegTask("task.test", func(cxt context.Context, param *RunReq) string { return "" }, BindParams(c.proto, ParamKV))
+			if got := len(e.ListTask()) == 1; got != c.ok {
+				t.Fatalf("registered = %v, want %v", got, c.ok)
+			}
+		})
+	}
+}
+
+func TestBoundParams(t *testing.T) {
+	e := newExecutor()
+	e.Init(Standalone())
+	got := make(chan interface{}, 1)
+	e.RegTask("task.test", func(cxt context.Context, param *RunReq) string {
+		got <- BoundParams(cxt)
+		return ""
+	}, BindParams(&testParams{}, ParamKV))
+	if _, ok := e.trigger(&RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.test", ExecutorParams: "date=2021-01-01,limit=3"}, true); !ok {
+		t.Fatal("trigger rejected")
+	}
+	select {
+	case v := <-got:
+		p, ok := v.(*testParams)
+		if !ok || p.Date != "2021-01-01" || p.Limit != 3 {
+			t.Fatalf("BoundParams() = %#v", v)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("task did not run")
+	}
+	if BoundParams(context.Background()) != nil {
+		t.Fatal("BoundParams outside task should be nil")
+	}
+}
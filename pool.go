@@ -0,0 +1,153 @@
+package xxl
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/**
+并发限制:设置MaxConcurrency后同时执行的任务数不超过该值,超出的调度进入等待队列,
+按优先级(Priority)和等待时间出队,每等待PriorityAging优先级加1,防止低优先级任务一直得不到执行。
+排队中的任务计入运行列表,阻塞策略、终止对其同样生效;限流等待结束后才进入执行池,取得并发时才算开始执行,
+任务超时(ExecutorTimeout)从开始执行时计算
+*/
+
+// Priority 设置任务优先级,执行器并发已满时优先级高的调度先执行,默认0
+func Priority(priority int) TaskOption {
+	return func(o *taskOptions) {
+		o.priority = priority
+	}
+}
+
+// 任务执行池
+type pool struct {
+	mu      sync.Mutex
+	running int           //占用的并发数
+	seq     int64         //入队序号
+	queue   []*queuedTask //等待队列
+}
+
+type queuedTask struct {
+	task *Task
+	run  func()
+	at   time.Time
+	seq  int64
+}
+
+// 提交任务,需要限流等待时等待结束后再进入执行池,等待期间不占用并发
+func (e *executor) submit(task *Task, run func()) {
+	if task.limitDelay <= 0 {
+		e.dispatch(task, run)
+		return
+	}
+	go func() {
+		if task.waitLimit() {
+			e.dispatch(task, run)
+			return
+		}
+		run()
+	}()
+}
+
+// 提交任务,并发未满时立即执行,否则进入等待队列;已终止的任务不占用并发直接结束
+func (e *executor) dispatch(task *Task, run func()) {
+	if task.Ext.Err() != nil {
+		go run()
+		return
+	}
+	p := e.pool
+	p.mu.Lock()
+	if e.opts.MaxConcurrency <= 0 || p.running < e.opts.MaxConcurrency {
+		p.running++
+		p.mu.Unlock()
+		task.slot = true
+		go run()
+		return
+	}
+	p.seq++
+	atomic.StoreInt32(&task.queued, 1)
+	p.queue = append(p.queue, &queuedTask{task: task, run: run, at: time.Now(), seq: p.seq})
+	n := len(p.queue)
+	p.mu.Unlock()
+	task.log.Info("执行器并发已满,任务排队", F("priority", task.opts.priority), F("queued", n))
+	task.jlog.Write("执行器并发已满,排队等待 优先级:%d", task.opts.priority)
+}
+
+// 任务结束释放并发,取出等待队列中优先级最高的任务执行
+func (e *executor) release(task *Task) {
+	if !task.slot {
+		return
+	}
+	p := e.pool
+	p.mu.Lock()
+	q := p.next(e.opts.PriorityAging)
+	if q == nil {
+		p.running--
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+	q.task.slot = true
+	atomic.StoreInt32(&q.task.queued, 0)
+	go q.run()
+}
+
+// 从等待队列移除任务并立即结束,用于终止排队中的任务,返回是否在队列中
+func (e *executor) dequeue(task *Task) bool {
+	p := e.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, q := range p.queue {
+		if q.task == task {
+			p.queue = append(p.queue[:i], p.queue[i+1:]...)
+			atomic.StoreInt32(&task.queued, 0)
+			go q.run()
+			return true
+		}
+	}
+	return false
+}
+
+// 取出有效优先级最高的任务,有效优先级=优先级+等待时间/aging,相同时先入队的优先
+func (p *pool) next(aging time.Duration) *queuedTask {
+	if len(p.queue) == 0 {
+		return nil
+	}
+	now := time.Now()
+	effective := func(q *queuedTask) int {
+		prio := q.task.opts.priority
+		if aging > 0 {
+			prio += int(now.Sub(q.at) / aging)
+		}
+		return prio
+	}
+	best := 0
+	for i := 1; i < len(p.queue); i++ {
+		a, b := effective(p.queue[i]), effective(p.queue[best])
+		if a > b || (a == b && p.queue[i].seq < p.queue[best].seq) {
+			best = i
+		}
+	}
+	q := p.queue[best]
+	p.queue = append(p.queue[:best], p.queue[best+1:]...)
+	return q
+}
+
+// 等待队列长度
+func (p *pool) queued() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.queue)
+}
+
+// 并发是否已满
+func (e *executor) saturated() bool {
+	if e.opts.MaxConcurrency <= 0 {
+		return false
+	}
+	p := e.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.running >= e.opts.MaxConcurrency
+}
@@ -0,0 +1,157 @@
+package xxl
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoolNext(t *testing.T) {
+	now := time.Now()
+	queued := func(prio int, wait time.Duration, seq int64) *queuedTask {
+		return &queuedTask{task: &Task{opts: taskOptions{priority: prio}}, at: now.Add(-wait), seq: seq}
+	}
+	cases := []struct {
+		name  string
+		aging time.Duration
+		queue []*queuedTask
+		want  int64
+	}{
+		{"priority", 0, []*queuedTask{queued(0, 0, 1), queued(5, 0, 2), queued(1, 0, 3)}, 2},
+		{"fifo", 0, []*queuedTask{queued(1, 0, 2), queued(1, 0, 1)}, 1},
+		{"aging", time.Second, []*queuedTask{queued(0, 10*time.Second, 1), queued(5, 0, 2)}, 1},
+		{"no aging", 0, []*queuedTask{queued(0, 10*time.Second, 1), queued(5, 0, 2)}, 2},
+	}
+	for _, c := range cases {
+		p := &pool{queue: c.queue}
+		n := len(c.queue)
+		if q := p.next(c.aging); q == nil || q.seq != c.want {
+			t.Errorf("%s: next() = %+v, want seq %d", c.name, q, c.want)
+		}
+		if len(p.queue) != n-1 {
+			t.Errorf("%s: queue len = %d, want %d", c.name, len(p.queue), n-1)
+		}
+	}
+}
+
+// 排队中的任务未开始:没有开始时间和开始事件,终止后直接结束
+func TestQueuedTaskNotStarted(t *testing.T) {
+	e := newExecutor()
+	e.Init(Standalone(), MaxConcurrency(1))
+	var mu sync.Mutex
+	started := map[int64]int{}
+	e.AddListener(EventListenerFunc(func(ev Event) {
+		if ev.Type == EventTaskStart {
+			mu.Lock()
+			started[ev.LogID]++
+			mu.Unlock()
+		}
+	}))
+	release := make(chan struct{})
+	e.RegTask("task.block", func(cxt context.Context, param *RunReq) string {
+		<-release
+		return ""
+	})
+	for i := int64(1); i <= 3; i++ {
+		if _, ok := e.trigger(&RunReq{JobID: i, LogID: i, ExecutorHandler: "task.block"}, true); !ok {
+			t.Fatalf("trigger %d rejected", i)
+		}
+	}
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return started[1] == 1
+	})
+	for _, r := range e.Running() {
+		if r.Queued != r.StartTime.IsZero() {
+			t.Fatalf("log %d: queued=%v startTime=%v", r.LogID, r.Queued, r.StartTime)
+		}
+	}
+	e.KillLog(3)
+	close(release)
+	waitFor(t, func() bool { return len(e.History()) == 3 })
+
+	mu.Lock()
+	defer mu.Unlock()
+	if started[1] != 1 || started[2] != 1 || started[3] != 0 {
+		t.Fatalf("start events = %v, want logs 1 and 2 only", started)
+	}
+	for _, r := range e.History() {
+		if r.LogID == 3 && (!r.StartTime.IsZero() || r.Duration != 0 || r.Msg != "task killed") {
+			t.Fatalf("killed queued run = %+v", r)
+		}
+		if r.LogID != 3 && r.StartTime.IsZero() {
+			t.Fatalf("log %d has no start time", r.LogID)
+		}
+	}
+}
+
+// 限流等待期间不占用并发
+func TestRateLimitWaitNoSlot(t *testing.T) {
+	e := newExecutor()
+	e.Init(Standalone(), MaxConcurrency(1))
+	defer e.KillAll()
+	e.RegTask("task.limited", func(cxt context.Context, param *RunReq) string {
+		return ""
+	}, RateLimit(0.1, 1, RateLimitWait))
+	ran := make(chan struct{})
+	e.RegTask("task.other", func(cxt context.Context, param *RunReq) string {
+		close(ran)
+		return ""
+	})
+	e.trigger(&RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.limited"}, true)
+	waitFor(t, func() bool { return len(e.History()) == 1 })
+	e.trigger(&RunReq{JobID: 1, LogID: 2, ExecutorHandler: "task.limited"}, true)
+	waitFor(t, func() bool {
+		r := e.Running()
+		return len(r) == 1 && r[0].Limiting
+	})
+	if r := e.Running()[0]; !r.StartTime.IsZero() || r.Queued {
+		t.Fatalf("limited run = %+v, want not started and not queued", r)
+	}
+	e.trigger(&RunReq{JobID: 2, LogID: 3, ExecutorHandler: "task.other"}, true)
+	select {
+	case <-ran:
+	case <-time.After(3 * time.Second):
+		t.Fatal("run waiting for rate limit holds the only slot")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// 超时从取得并发开始计算,排队时间不计入
+func TestQueuedTaskTimeout(t *testing.T) {
+	e := newExecutor()
+	e.Init(Standalone(), MaxConcurrency(1))
+	e.RegTask("task.sleep", func(cxt context.Context, param *RunReq) string {
+		select {
+		case <-time.After(1500 * time.Millisecond):
+		case <-cxt.Done():
+		}
+		return ""
+	})
+	e.RegTask("task.quick", func(cxt context.Context, param *RunReq) string {
+		return ""
+	})
+	e.trigger(&RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.sleep"}, true)
+	e.trigger(&RunReq{JobID: 2, LogID: 2, ExecutorHandler: "task.quick", ExecutorTimeout: 1}, true)
+	waitFor(t, func() bool { return len(e.History()) == 2 })
+	for _, r := range e.History() {
+		if r.Code != SuccessCode {
+			t.Fatalf("log %d: code=%d msg=%s, want success", r.LogID, r.Code, r.Msg)
+		}
+	}
+	if n := len(e.Zombies()); n != 0 {
+		t.Fatalf("zombies = %d, want 0", n)
+	}
+}
@@ -0,0 +1,92 @@
+package xxl
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Progress 任务进度
+type Progress struct {
+	Processed int64     `json:"processed"` //已处理数量
+	Total     int64     `json:"total"`     //总数量,0为未知
+	Percent   float64   `json:"percent"`   //完成百分比 0~100
+	Msg       string    `json:"msg"`       //进度说明
+	UpdatedAt time.Time `json:"updatedAt"` //更新时间
+}
+
+// String 进度格式: 50.00% 50/100 msg
+func (p Progress) String() string {
+	s := fmt.Sprintf("%.2f%%", p.Percent)
+	if p.Total > 0 {
+		s += fmt.Sprintf(" %d/%d", p.Processed, p.Total)
+	}
+	if p.Msg != "" {
+		s += " " + p.Msg
+	}
+	return s
+}
+
+// ReportProgress 上报任务进度,按processed/total计算百分比,写入任务日志,可在运行状态中查看,并附加到回调信息
+func ReportProgress(ctx context.Context, processed, total int64, msg string) {
+	p := Progress{Processed: processed, Total: total, Msg: msg}
+	if total > 0 {
+		p.Percent = float64(processed) * 100 / float64(total)
+	}
+	setProgress(ctx, p)
+}
+
+// ReportPercent 按百分比上报任务进度
+func ReportPercent(ctx context.Context, percent float64, msg string) {
+	setProgress(ctx, Progress{Percent: percent, Msg: msg})
+}
+
+func setProgress(ctx context.Context, p Progress) {
+	t := taskFrom(ctx)
+	if t == nil {
+		return
+	}
+	if p.Percent < 0 {
+		p.Percent = 0
+	} else if p.Percent > 100 {
+		p.Percent = 100
+	}
+	p.UpdatedAt = time.Now()
+	t.mu.Lock()
+	t.progress = &p
+	t.mu.Unlock()
+	t.jlog.Write("进度:%s", p)
+	if t.onProgress != nil {
+		t.onProgress(t, p)
+	}
+}
+
+// 最近一次上报的进度,未上报时返回nil
+func (t *Task) lastProgress() *Progress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.progress == nil {
+		return nil
+	}
+	p := *t.progress
+	return &p
+}
+
+// 进度更新
+func (e *executor) onProgress(t *Task, p Progress) {
+	e.counters.add(&e.counters.progress)
+	ev := runEvent(EventTaskProgress, t.Param)
+	ev.Msg = p.String()
+	e.emit(ev)
+}
+
+// 回调信息附加最终进度
+func progressMsg(msg string, p *Progress) string {
+	if p == nil {
+		return msg
+	}
+	if msg == "" {
+		return "[进度:" + p.String() + "]"
+	}
+	return msg + " [进度:" + p.String() + "]"
+}
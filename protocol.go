@@ -0,0 +1,74 @@
+package xxl
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+/**
+调度中心版本差异只在任务结果回调参数:2.2使用executeResult(ReturnT),2.3及以上使用handleCode、handleMsg。
+2.1及以前的调度中心通过xxl-rpc(Hessian)调用执行器,不使用本HTTP/JSON协议,AdminVersion21按2.2处理。
+调度中心下发的请求(run、kill、log、beat、idleBeat)在2.2~2.4字段一致,统一解析。
+testdata/protocol 中的请求报文按调度中心模型类(TriggerParam等)和Gson序列化规则(省略null字段)整理,
+回调参数在测试中按调度中心的HandleCallbackParam模型严格解析
+*/
+
+// 调度中心(xxl-job-admin)版本
+const (
+	AdminVersionCompat = ""    //兼容模式,回调同时携带2.2与2.3字段
+	AdminVersion21     = "2.1" //v2.1.x 不支持HTTP协议,等同于AdminVersion22
+	AdminVersion22     = "2.2" //v2.2.x
+	AdminVersion23     = "2.3" //v2.3.x
+	AdminVersion24     = "2.4" //v2.4.x 及以上
+)
+
+// v2.2.x 回调参数
+type callElementV22 struct {
+	LogID         int64          `json:"logId"`
+	LogDateTim    int64          `json:"logDateTim"`
+	ExecuteResult *ExecuteResult `json:"executeResult"`
+}
+
+// v2.3.0 及以上回调参数
+type callElementV23 struct {
+	LogID      int64  `json:"logId"`
+	LogDateTim int64  `json:"logDateTim"`
+	HandleCode int    `json:"handleCode"` //200表示正常,500表示失败
+	HandleMsg  string `json:"handleMsg"`
+}
+
+// 校验调度中心版本
+func checkAdminVersion(version string) error {
+	switch version {
+	case AdminVersionCompat, AdminVersion21, AdminVersion22, AdminVersion23, AdminVersion24:
+		return nil
+	}
+	return fmt.Errorf("unsupported admin version %q", version)
+}
+
+// 按调度中心版本编码回调参数
+func encodeCall(version string, req *RunReq, code int64, msg string) []byte {
+	var data interface{}
+	switch version {
+	case AdminVersion21, AdminVersion22:
+		data = []*callElementV22{{
+			LogID:      req.LogID,
+			LogDateTim: req.LogDateTime,
+			ExecuteResult: &ExecuteResult{
+				Code: code,
+				Msg:  msg,
+			},
+		}}
+	case AdminVersion23, AdminVersion24:
+		data = []*callElementV23{{
+			LogID:      req.LogID,
+			LogDateTim: req.LogDateTime,
+			HandleCode: int(code),
+			HandleMsg:  msg,
+		}}
+	default:
+		return returnCall(req, code, msg)
+	}
+	str, _ := json.Marshal(data)
+	return str
+}
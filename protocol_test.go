@@ -0,0 +1,137 @@
+package xxl
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// 读取调度中心报文
+func readPayload(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "protocol", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// 调度中心回调模型 com.xxl.job.core.biz.model.HandleCallbackParam
+// v2.2.x: logId、logDateTim、executeResult(ReturnT<String>: code、msg、content)
+type adminCallbackV22 struct {
+	LogID         int64 `json:"logId"`
+	LogDateTim    int64 `json:"logDateTim"`
+	ExecuteResult *struct {
+		Code    int     `json:"code"`
+		Msg     string  `json:"msg"`
+		Content *string `json:"content"`
+	} `json:"executeResult"`
+}
+
+// v2.3.0及以上: logId、logDateTim、handleCode、handleMsg
+type adminCallbackV23 struct {
+	LogID      int64  `json:"logId"`
+	LogDateTim int64  `json:"logDateTim"`
+	HandleCode int    `json:"handleCode"`
+	HandleMsg  string `json:"handleMsg"`
+}
+
+// 按调度中心模型解析回调参数,strict时不允许模型之外的字段
+func decodeCallback(t *testing.T, data []byte, v interface{}, strict bool) {
+	t.Helper()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		t.Fatalf("decode %s: %v", data, err)
+	}
+}
+
+func TestEncodeCall(t *testing.T) {
+	req := &RunReq{LogID: 1001, LogDateTime: 1700000000000}
+	checkV22 := func(version string, strict bool) {
+		var list []adminCallbackV22
+		decodeCallback(t, encodeCall(version, req, FailureCode, "failed"), &list, strict)
+		if len(list) != 1 || list[0].LogID != 1001 || list[0].LogDateTim != 1700000000000 ||
+			list[0].ExecuteResult == nil || list[0].ExecuteResult.Code != 500 || list[0].ExecuteResult.Msg != "failed" {
+			t.Errorf("version %q: 2.2 callback = %+v", version, list)
+		}
+	}
+	checkV23 := func(version string, strict bool) {
+		var list []adminCallbackV23
+		decodeCallback(t, encodeCall(version, req, FailureCode, "failed"), &list, strict)
+		if len(list) != 1 || list[0] != (adminCallbackV23{LogID: 1001, LogDateTim: 1700000000000, HandleCode: 500, HandleMsg: "failed"}) {
+			t.Errorf("version %q: 2.3 callback = %+v", version, list)
+		}
+	}
+	checkV22(AdminVersion21, true)
+	checkV22(AdminVersion22, true)
+	checkV23(AdminVersion23, true)
+	checkV23(AdminVersion24, true)
+	//兼容模式同时满足两种模型,Gson忽略模型之外的字段
+	checkV22(AdminVersionCompat, false)
+	checkV23(AdminVersionCompat, false)
+}
+
+func TestCheckAdminVersion(t *testing.T) {
+	for _, v := range []string{AdminVersionCompat, AdminVersion21, AdminVersion22, AdminVersion23, AdminVersion24} {
+		if err := checkAdminVersion(v); err != nil {
+			t.Errorf("version %q: %v", v, err)
+		}
+	}
+	if err := checkAdminVersion("3.0"); err == nil {
+		t.Error("version 3.0 should be rejected")
+	}
+}
+
+// 2.2~2.4调度中心下发的请求字段一致,使用严格解析校验报文
+func TestDecodeAdminRequests(t *testing.T) {
+	e := newExecutor(DisallowUnknownFields())
+	decode := func(name string, v interface{}) {
+		t.Helper()
+		request := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(readPayload(t, name)))
+		request.Header.Set("Content-Type", "application/json;charset=UTF-8")
+		if err := e.decode(httptest.NewRecorder(), request, v); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+	want := RunReq{
+		JobID:                 1,
+		ExecutorHandler:       "task.test",
+		ExecutorParams:        "a=1",
+		ExecutorBlockStrategy: serialExecution,
+		LogID:                 1001,
+		LogDateTime:           1700000000000,
+		GlueType:              "BEAN",
+		GlueUpdatetime:        1700000000000,
+		BroadcastTotal:        1,
+	}
+	var req RunReq
+	decode("run.json", &req)
+	if req != want {
+		t.Errorf("run: got %+v, want %+v", req, want)
+	}
+	if err := req.validate(); err != nil {
+		t.Errorf("run: %v", err)
+	}
+	var kill killReq
+	decode("kill.json", &kill)
+	if kill.JobID != 1 {
+		t.Errorf("kill: got %+v", kill)
+	}
+	var idle idleBeatReq
+	decode("idleBeat.json", &idle)
+	if idle.JobID != 1 {
+		t.Errorf("idleBeat: got %+v", idle)
+	}
+	var log LogReq
+	decode("log.json", &log)
+	if log != (LogReq{LogDateTim: 1700000000000, LogID: 1001, FromLineNum: 1}) {
+		t.Errorf("log: got %+v", log)
+	}
+}
@@ -0,0 +1,95 @@
+package xxl
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RateLimitMode 超出限流时的处理方式
+type RateLimitMode int
+
+// 超出限流时的处理方式
+const (
+	RateLimitReject RateLimitMode = iota //拒绝调度,返回调度中心失败
+	RateLimitWait                        //接受调度,等待到允许时再执行,等待时间不计入任务超时
+)
+
+// RateLimit 按handler令牌桶限流,rate为每秒允许的调度次数,burst为桶容量
+func RateLimit(rate float64, burst int, mode RateLimitMode) TaskOption {
+	return func(o *taskOptions) {
+		o.limiter = newRateLimiter(rate, burst, mode)
+	}
+}
+
+// 令牌桶
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	mode   RateLimitMode
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int, mode RateLimitMode) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), mode: mode, tokens: float64(burst), last: time.Now()}
+}
+
+// 获取一个令牌,返回需要等待的时间;拒绝模式下令牌不足时返回false
+func (l *rateLimiter) reserve() (time.Duration, bool) {
+	if l == nil || l.rate <= 0 {
+		return 0, true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0, true
+	}
+	if l.mode == RateLimitReject {
+		return 0, false
+	}
+	//等待模式预支令牌,按欠缺的令牌数计算等待时间
+	l.tokens--
+	return time.Duration(-l.tokens / l.rate * float64(time.Second)), true
+}
+
+// 归还reserve获取的令牌,调度被其他原因拒绝时调用
+func (l *rateLimiter) cancel() {
+	if l == nil || l.rate <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.tokens++; l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// 等待限流,任务取消时返回false
+func (t *Task) waitLimit() bool {
+	if t.limitDelay <= 0 {
+		return true
+	}
+	t.jlog.Write("限流等待:%s", t.limitDelay)
+	atomic.StoreInt32(&t.limiting, 1)
+	defer atomic.StoreInt32(&t.limiting, 0)
+	timer := time.NewTimer(t.limitDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-t.Ext.Done():
+		return false
+	}
+}
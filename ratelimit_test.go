@@ -0,0 +1,87 @@
+package xxl
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	l := newRateLimiter(0.001, 2, RateLimitReject)
+	for i := 0; i < 2; i++ {
+		if d, ok := l.reserve(); !ok || d != 0 {
+			t.Fatalf("reserve %d = %v, %v, want 0, true", i, d, ok)
+		}
+	}
+	if _, ok := l.reserve(); ok {
+		t.Fatal("reserve over burst should be rejected")
+	}
+	l.cancel()
+	if _, ok := l.reserve(); !ok {
+		t.Fatal("reserve after cancel should be allowed")
+	}
+
+	w := newRateLimiter(1, 1, RateLimitWait)
+	w.reserve()
+	if d, ok := w.reserve(); !ok || d <= 0 {
+		t.Fatalf("wait mode reserve = %v, %v, want delay", d, ok)
+	}
+}
+
+// 被限流的覆盖调度不能终止正在运行的任务
+func TestRateLimitBeforeBlockStrategy(t *testing.T) {
+	e := newExecutor()
+	e.Init(Standalone())
+	e.RegTask("task.wait", func(cxt context.Context, param *RunReq) string {
+		<-cxt.Done()
+		return ""
+	}, RateLimit(0.001, 2, RateLimitReject))
+	defer e.KillAll()
+
+	if _, ok := e.trigger(&RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.wait"}, true); !ok {
+		t.Fatal("first trigger rejected")
+	}
+	//单机串行拒绝时归还令牌
+	if msg, ok := e.trigger(&RunReq{JobID: 1, LogID: 2, ExecutorHandler: "task.wait"}, true); ok || msg != "There are tasks running" {
+		t.Fatalf("serial trigger = %q, %v", msg, ok)
+	}
+	if _, ok := e.trigger(&RunReq{JobID: 1, LogID: 3, ExecutorHandler: "task.wait", ExecutorBlockStrategy: coverEarly}, true); !ok {
+		t.Fatal("cover trigger should use the refunded token")
+	}
+	if msg, ok := e.trigger(&RunReq{JobID: 1, LogID: 4, ExecutorHandler: "task.wait", ExecutorBlockStrategy: coverEarly}, true); ok || msg != "Rate limit exceeded" {
+		t.Fatalf("limited cover trigger = %q, %v", msg, ok)
+	}
+	found := false
+	for _, r := range e.Running() {
+		if r.LogID == 3 {
+			found = !r.Zombie
+		} else if !r.Zombie {
+			t.Fatalf("running log %d, want only log 3", r.LogID)
+		}
+	}
+	if !found {
+		t.Fatal("log 3 was killed by a rate limited trigger")
+	}
+}
+
+// 限流等待不计入任务超时,也不会导致任务在开始前被取消
+func TestRateLimitWaitTimeout(t *testing.T) {
+	e := newExecutor()
+	e.Init(Standalone())
+	e.RegTask("task.limited", func(cxt context.Context, param *RunReq) string {
+		return ""
+	}, RateLimit(1, 1, RateLimitWait))
+	for i := int64(1); i <= 2; i++ {
+		if _, ok := e.trigger(&RunReq{JobID: i, LogID: i, ExecutorHandler: "task.limited", ExecutorTimeout: 1}, true); !ok {
+			t.Fatalf("trigger %d rejected", i)
+		}
+	}
+	waitFor(t, func() bool { return len(e.History()) == 2 })
+	for _, r := range e.History() {
+		if r.Code != SuccessCode {
+			t.Fatalf("log %d: code=%d msg=%s, want success", r.LogID, r.Code, r.Msg)
+		}
+	}
+	if n := len(e.Zombies()); n != 0 {
+		t.Fatalf("zombies = %d, want 0", n)
+	}
+}
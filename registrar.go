@@ -0,0 +1,124 @@
+package xxl
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// Registrar 服务注册,registry、registryRemove均通过该接口完成,默认注册到调度中心的 /api/registry。
+// ctx在执行器停止时取消(摘除时带超时),实现需在ctx结束后尽快返回,否则Stop会一直等待
+type Registrar interface {
+	// Name 注册目标名称,用于注册状态和日志
+	Name() string
+	// Registry 注册(心跳)
+	Registry(ctx context.Context, req *Registry) error
+	// RegistryRemove 摘除
+	RegistryRemove(ctx context.Context, req *Registry) error
+}
+
+// 注册到调度中心
+type adminRegistrar struct {
+	e    *executor
+	addr string
+}
+
+func (a *adminRegistrar) Name() string {
+	return a.addr
+}
+
+func (a *adminRegistrar) Registry(ctx context.Context, req *Registry) error {
+	return a.call(ctx, "/api/registry", req)
+}
+
+func (a *adminRegistrar) RegistryRemove(ctx context.Context, req *Registry) error {
+	return a.call(ctx, "/api/registryRemove", req)
+}
+
+func (a *adminRegistrar) call(ctx context.Context, action string, req *Registry) error {
+	param, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	_, err = a.e.adminCall(ctx, a.addr, action, param)
+	return err
+}
+
+// StaticRegistrar 静态地址模式,不做注册,调度中心使用手动录入的执行器地址
+func StaticRegistrar() Registrar {
+	return staticRegistrar{}
+}
+
+type staticRegistrar struct{}
+
+func (staticRegistrar) Name() string                                            { return "static" }
+func (staticRegistrar) Registry(ctx context.Context, req *Registry) error       { return nil }
+func (staticRegistrar) RegistryRemove(ctx context.Context, req *Registry) error { return nil }
+
+// FileRegistrar 文件注册,将执行器地址按RegistryKey写入JSON文件,
+// 格式为 {"golang-jobs":["http://127.0.0.1:9999"]},可用于测试或外部服务发现
+func FileRegistrar(path string) Registrar {
+	return &fileRegistrar{path: path}
+}
+
+type fileRegistrar struct {
+	mu   sync.Mutex
+	path string
+}
+
+func (f *fileRegistrar) Name() string {
+	return "file:" + f.path
+}
+
+func (f *fileRegistrar) Registry(ctx context.Context, req *Registry) error {
+	return f.update(func(values []string) []string {
+		for _, v := range values {
+			if v == req.RegistryValue {
+				return values
+			}
+		}
+		return append(values, req.RegistryValue)
+	}, req.RegistryKey)
+}
+
+func (f *fileRegistrar) RegistryRemove(ctx context.Context, req *Registry) error {
+	return f.update(func(values []string) []string {
+		res := values[:0]
+		for _, v := range values {
+			if v != req.RegistryValue {
+				res = append(res, v)
+			}
+		}
+		return res
+	}, req.RegistryKey)
+}
+
+func (f *fileRegistrar) update(fn func(values []string) []string, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data := make(map[string][]string)
+	content, err := ioutil.ReadFile(f.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(content) > 0 {
+		if err = json.Unmarshal(content, &data); err != nil {
+			return err
+		}
+	}
+	data[key] = fn(data[key])
+	if len(data[key]) == 0 {
+		delete(data, key)
+	}
+	content, err = json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := f.path + ".tmp"
+	if err = ioutil.WriteFile(tmp, content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
@@ -0,0 +1,228 @@
+package xxl
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AdminStatus 单个调度中心的注册状态
+type AdminStatus struct {
+	Addr        string    `json:"addr"`        //调度中心地址(注册目标名称)
+	Registered  bool      `json:"registered"`  //最近一次注册是否成功
+	LastSuccess time.Time `json:"lastSuccess"` //最近一次注册成功时间
+	LastError   string    `json:"lastError"`   //最近一次注册失败原因
+	LastErrorAt time.Time `json:"lastErrorAt"` //最近一次注册失败时间
+	Failures    int       `json:"failures"`    //连续失败次数
+}
+
+// RegistryStatus 注册状态
+type RegistryStatus struct {
+	Running bool          `json:"running"` //注册循环是否在运行
+	Admins  []AdminStatus `json:"admins"`  //各调度中心注册状态
+}
+
+// 注册循环及状态
+type registry struct {
+	mu      sync.Mutex
+	running bool
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	status  map[string]*AdminStatus
+}
+
+// 更新注册状态,返回更新前后的状态
+func (r *registry) update(addr string, err error) (prev, st AdminStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.status[addr]
+	if !ok {
+		s = &AdminStatus{Addr: addr}
+		r.status[addr] = s
+	}
+	prev = *s
+	if err != nil {
+		s.Registered = false
+		s.LastError = err.Error()
+		s.LastErrorAt = time.Now()
+		s.Failures++
+	} else {
+		s.Registered = true
+		s.LastSuccess = time.Now()
+		s.Failures = 0
+	}
+	return prev, *s
+}
+
+// RegistryStatus 注册状态
+func (e *executor) RegistryStatus() RegistryStatus {
+	r := e.reg
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := RegistryStatus{Running: r.running}
+	for _, rr := range e.registrars {
+		if s, ok := r.status[rr.Name()]; ok {
+			st.Admins = append(st.Admins, *s)
+		}
+	}
+	return st
+}
+
+// 启动注册循环,每个注册目标独立注册
+func (e *executor) startRegistry() {
+	r := e.reg
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.running = true
+	for _, rr := range e.registrars {
+		if _, ok := r.status[rr.Name()]; !ok {
+			r.status[rr.Name()] = &AdminStatus{Addr: rr.Name()}
+		}
+		r.wg.Add(1)
+		go e.registryLoop(ctx, rr)
+	}
+}
+
+// 停止注册循环并等待退出
+func (e *executor) stopRegistry() {
+	r := e.reg
+	r.mu.Lock()
+	if !r.running {
+		r.mu.Unlock()
+		return
+	}
+	r.running = false
+	r.cancel()
+	r.mu.Unlock()
+	r.wg.Wait()
+}
+
+// 注册执行器到调度中心
+func (e *executor) registryLoop(ctx context.Context, rr Registrar) {
+	defer e.reg.wg.Done()
+	t := time.NewTimer(time.Second * 0) //初始立即执行
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		addr := rr.Name()
+		err := rr.Registry(ctx, e.registryReq())
+		prev, st := e.reg.update(addr, err)
+		if err != nil {
+			e.log.Error("执行器注册失败", F(FieldAdminAddr, addr), F("failures", st.Failures), F(FieldError, err))
+			if e.opts.onRegistryFailure != nil {
+				e.registryHook(addr, func() { e.opts.onRegistryFailure(addr, err, st.Failures) })
+			}
+			e.emit(Event{Type: EventRegistryFailed, Addr: addr, Err: err, Msg: err.Error()})
+			if prev.Registered {
+				e.emit(Event{Type: EventRegistryLost, Addr: addr, Err: err, Msg: err.Error()})
+			}
+			t.Reset(backoff(e.opts.RegistryBackoffMin, e.registryBackoffMax(), st.Failures))
+			continue
+		}
+		if prev.Failures > 0 {
+			e.log.Info("执行器注册恢复", F(FieldAdminAddr, addr), F("failures", prev.Failures))
+			e.emit(Event{Type: EventRegistryRecovered, Addr: addr})
+		} else {
+			e.log.Debug("执行器注册成功", F(FieldAdminAddr, addr))
+		}
+		if e.opts.onRegistrySuccess != nil {
+			e.registryHook(addr, func() { e.opts.onRegistrySuccess(addr) })
+		}
+		t.Reset(e.registryInterval()) //心跳防止过期
+	}
+}
+
+// 执行注册回调,回调panic不影响注册循环
+func (e *executor) registryHook(addr string, fn func()) {
+	defer func() {
+		if err := recover(); err != nil {
+			e.log.Error("注册回调panic", F(FieldAdminAddr, addr), F("panic", err))
+		}
+	}()
+	fn()
+}
+
+// 执行器注册摘除
+func (e *executor) registryRemove() {
+	timeout := e.opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req := e.registryReq()
+	for _, rr := range e.registrars {
+		if err := rr.RegistryRemove(ctx, req); err != nil {
+			e.log.Error("执行器摘除失败", F(FieldAdminAddr, rr.Name()), F(FieldError, err))
+			continue
+		}
+		e.log.Info("执行器摘除成功", F(FieldAdminAddr, rr.Name()))
+	}
+}
+
+// 注册参数
+func (e *executor) registryReq() *Registry {
+	return &Registry{
+		RegistryGroup: "EXECUTOR",
+		RegistryKey:   e.opts.RegistryKey,
+		RegistryValue: e.registryValue(),
+	}
+}
+
+// 注册心跳间隔,未设置或非正数时使用默认值
+func (e *executor) registryInterval() time.Duration {
+	if e.opts.RegistryInterval > 0 {
+		return e.opts.RegistryInterval
+	}
+	return DefaultRegistryInterval
+}
+
+// 失败重试的最大间隔,默认为注册间隔
+func (e *executor) registryBackoffMax() time.Duration {
+	if e.opts.RegistryBackoffMax > 0 {
+		return e.opts.RegistryBackoffMax
+	}
+	return e.registryInterval()
+}
+
+// 指数退避,在[d/2, d]之间随机抖动;min非正数时使用默认值,max小于min时取min,避免无间隔重试
+func backoff(min, max time.Duration, failures int) time.Duration {
+	if min <= 0 {
+		min = DefaultRegistryBackoff
+	}
+	if max < min {
+		max = min
+	}
+	d := min
+	for i := 1; i < failures && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// 解析调度中心地址,多个地址用逗号分隔
+func adminAddrs(serverAddr string) []string {
+	var addrs []string
+	for _, addr := range strings.Split(serverAddr, ",") {
+		addr = strings.TrimRight(strings.TrimSpace(addr), "/")
+		if addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
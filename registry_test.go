@@ -0,0 +1,111 @@
+package xxl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		min, max time.Duration
+		failures int
+		lo, hi   time.Duration
+	}{
+		{time.Second, time.Minute, 1, time.Second / 2, time.Second},
+		{time.Second, time.Minute, 3, 2 * time.Second, 4 * time.Second},
+		{time.Second, 3 * time.Second, 10, 1500 * time.Millisecond, 3 * time.Second},
+		{0, 0, 5, DefaultRegistryBackoff / 2, DefaultRegistryBackoff},
+		{-time.Second, time.Minute, 1, DefaultRegistryBackoff / 2, DefaultRegistryBackoff},
+		{2 * time.Second, 0, 5, time.Second, 2 * time.Second},
+	}
+	for _, c := range cases {
+		for i := 0; i < 20; i++ {
+			if d := backoff(c.min, c.max, c.failures); d < c.lo || d > c.hi {
+				t.Fatalf("backoff(%v, %v, %d) = %v, want [%v, %v]", c.min, c.max, c.failures, d, c.lo, c.hi)
+			}
+		}
+	}
+}
+
+func TestRegistryIntervalDefault(t *testing.T) {
+	e := newExecutor(RegistryInterval(0), RegistryBackoff(0, 0))
+	if d := e.registryInterval(); d != DefaultRegistryInterval {
+		t.Fatalf("registryInterval = %v, want %v", d, DefaultRegistryInterval)
+	}
+	if d := e.registryBackoffMax(); d != DefaultRegistryInterval {
+		t.Fatalf("registryBackoffMax = %v, want %v", d, DefaultRegistryInterval)
+	}
+}
+
+type hookRegistrar struct {
+	calls chan struct{}
+	fail  bool
+}
+
+func (r *hookRegistrar) Name() string { return "hook" }
+
+func (r *hookRegistrar) Registry(ctx context.Context, req *Registry) error {
+	r.calls <- struct{}{}
+	if r.fail {
+		return errors.New("registry failed")
+	}
+	return nil
+}
+
+func (r *hookRegistrar) RegistryRemove(ctx context.Context, req *Registry) error { return nil }
+
+// 注册回调panic不能导致进程崩溃,注册循环继续运行
+func TestRegistryHookPanic(t *testing.T) {
+	for _, fail := range []bool{false, true} {
+		rr := &hookRegistrar{calls: make(chan struct{}, 10), fail: fail}
+		e := newExecutor()
+		e.Init(SetRegistrar(rr), RegistryInterval(time.Millisecond), RegistryBackoff(time.Millisecond, time.Millisecond),
+			OnRegistrySuccess(func(addr string) { panic("success hook") }),
+			OnRegistryFailure(func(addr string, err error, failures int) { panic("failure hook") }))
+		for i := 0; i < 2; i++ {
+			select {
+			case <-rr.calls:
+			case <-time.After(3 * time.Second):
+				t.Fatalf("fail=%v: registry loop stopped after hook panic", fail)
+			}
+		}
+		e.Stop()
+	}
+}
+
+// 调度中心无响应且HttpClient没有超时时,Stop不能一直等待
+func TestStopWithHangingAdmin(t *testing.T) {
+	hang := make(chan struct{})
+	called := make(chan struct{}, 10)
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called <- struct{}{}
+		select {
+		case <-hang:
+		case <-r.Context().Done():
+		}
+	}))
+	defer admin.Close()
+	defer close(hang)
+
+	e := newExecutor()
+	e.Init(ServerAddr(admin.URL), HttpClient(&http.Client{}), Timeout(100*time.Millisecond))
+	select {
+	case <-called:
+	case <-time.After(3 * time.Second):
+		t.Fatal("registry not called")
+	}
+	stopped := make(chan struct{})
+	go func() {
+		e.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Stop hangs on a registry request without timeout")
+	}
+}
@@ -0,0 +1,110 @@
+package xxl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// RequestError 请求错误,Status为返回的HTTP状态码
+type RequestError struct {
+	Status int
+	Msg    string
+}
+
+func (r *RequestError) Error() string {
+	return fmt.Sprintf("%d %s: %s", r.Status, http.StatusText(r.Status), r.Msg)
+}
+
+func newRequestError(status int, format string, a ...interface{}) *RequestError {
+	return &RequestError{Status: status, Msg: fmt.Sprintf(format, a...)}
+}
+
+// 校验请求方法
+func checkMethod(request *http.Request) error {
+	if request.Method != http.MethodPost {
+		return newRequestError(http.StatusMethodNotAllowed, "method %s not allowed", request.Method)
+	}
+	return nil
+}
+
+// 解析请求,校验方法、Content-Type、大小,并严格解析JSON
+func (e *executor) decode(writer http.ResponseWriter, request *http.Request, v interface{}) error {
+	defer request.Body.Close()
+	if err := checkMethod(request); err != nil {
+		return err
+	}
+	if ct := request.Header.Get("Content-Type"); ct != "" {
+		mt, _, err := mime.ParseMediaType(ct)
+		if err != nil || mt != "application/json" {
+			return newRequestError(http.StatusUnsupportedMediaType, "content type %q not supported", ct)
+		}
+	}
+	body := io.Reader(request.Body)
+	if e.opts.MaxRequestBody > 0 {
+		body = http.MaxBytesReader(writer, request.Body, e.opts.MaxRequestBody)
+	}
+	dec := json.NewDecoder(body)
+	if e.opts.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		if err == io.EOF {
+			return newRequestError(http.StatusBadRequest, "empty body")
+		}
+		if strings.Contains(err.Error(), "request body too large") {
+			return newRequestError(http.StatusRequestEntityTooLarge, "body exceeds %d bytes", e.opts.MaxRequestBody)
+		}
+		return newRequestError(http.StatusBadRequest, "invalid json: %v", err)
+	}
+	if dec.More() {
+		return newRequestError(http.StatusBadRequest, "invalid json: unexpected data after object")
+	}
+	return nil
+}
+
+// 错误返回,RequestError使用其状态码
+func writeError(writer http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	msg := err.Error()
+	if re, ok := err.(*RequestError); ok {
+		status = re.Status
+		msg = re.Msg
+	}
+	if status == http.StatusMethodNotAllowed {
+		writer.Header().Set("Allow", http.MethodPost)
+	}
+	writer.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	writer.WriteHeader(status)
+	_, _ = writer.Write(returnFailure(msg))
+}
+
+// 校验调度中心触发参数,运行列表按logId区分每次调度,logId必须为正数
+func (r *RunReq) validate() error {
+	if r.LogID <= 0 {
+		return newRequestError(http.StatusBadRequest, "logId must be positive: %d", r.LogID)
+	}
+	return r.validateFields()
+}
+
+// 校验触发参数中与调度日志无关的字段,本地调度的logId由执行器生成
+func (r *RunReq) validateFields() error {
+	if strings.TrimSpace(r.ExecutorHandler) == "" {
+		return newRequestError(http.StatusBadRequest, "executorHandler is required")
+	}
+	if r.ExecutorTimeout < 0 {
+		return newRequestError(http.StatusBadRequest, "executorTimeout must not be negative: %d", r.ExecutorTimeout)
+	}
+	switch r.ExecutorBlockStrategy {
+	case "", serialExecution, discardLater, coverEarly:
+	default:
+		return newRequestError(http.StatusBadRequest, "unknown executorBlockStrategy: %s", r.ExecutorBlockStrategy)
+	}
+	if r.BroadcastIndex < 0 || r.BroadcastTotal < 0 || (r.BroadcastTotal > 0 && r.BroadcastIndex >= r.BroadcastTotal) {
+		return newRequestError(http.StatusBadRequest, "invalid shard %d/%d", r.BroadcastIndex, r.BroadcastTotal)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package xxl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRunReqValidate(t *testing.T) {
+	valid := RunReq{ExecutorHandler: "task.test", LogID: 1}
+	cases := []struct {
+		name string
+		fn   func(r *RunReq)
+		ok   bool
+	}{
+		{"valid", func(r *RunReq) {}, true},
+		{"missing logId", func(r *RunReq) { r.LogID = 0 }, false},
+		{"negative logId", func(r *RunReq) { r.LogID = -1 }, false},
+		{"missing handler", func(r *RunReq) { r.ExecutorHandler = " " }, false},
+		{"negative timeout", func(r *RunReq) { r.ExecutorTimeout = -1 }, false},
+		{"unknown strategy", func(r *RunReq) { r.ExecutorBlockStrategy = "X" }, false},
+		{"cover early", func(r *RunReq) { r.ExecutorBlockStrategy = coverEarly }, true},
+		{"invalid shard", func(r *RunReq) { r.BroadcastIndex, r.BroadcastTotal = 2, 2 }, false},
+	}
+	for _, c := range cases {
+		r := valid
+		c.fn(&r)
+		err := r.validate()
+		if (err == nil) != c.ok {
+			t.Errorf("%s: err = %v", c.name, err)
+		}
+		if err != nil {
+			if re, ok := err.(*RequestError); !ok || re.Status != http.StatusBadRequest {
+				t.Errorf("%s: want 400 RequestError, got %#v", c.name, err)
+			}
+		}
+	}
+}
+
+func TestRunTaskRequiresLogID(t *testing.T) {
+	e := newExecutor()
+	e.RegTask("task.test", func(cxt context.Context, param *RunReq) string { return "" })
+	body := `{"jobId":1,"executorHandler":"task.test"}`
+	request := httptest.NewRequest(http.MethodPost, "/run", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	e.runTask(recorder, request)
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", recorder.Code)
+	}
+	if n := len(e.Running()); n != 0 {
+		t.Fatalf("running = %d, want 0", n)
+	}
+}
@@ -0,0 +1,119 @@
+package xxl
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+/**
+资源保护:定时采集内存、CPU使用率(Linux下优先读取cgroup,其次为进程自身),
+超过设置的阈值时拒绝新的调度,忙碌检测返回忙碌。非Linux平台不支持
+*/
+
+// ResourceUsage 资源使用情况
+type ResourceUsage struct {
+	MemoryUsage   uint64    `json:"memoryUsage"`   //内存使用量,字节,cgroup下为不含非活跃页缓存的工作集
+	MemoryLimit   uint64    `json:"memoryLimit"`   //内存上限,字节,cgroup未限制时为主机内存
+	MemoryPercent float64   `json:"memoryPercent"` //内存使用率 0~100
+	CPUPercent    float64   `json:"cpuPercent"`    //CPU使用率 0~100,相对于可用核数(cgroup配额)
+	CPUCores      float64   `json:"cpuCores"`      //可用核数
+	UpdatedAt     time.Time `json:"updatedAt"`     //采集时间,零值表示未采集
+}
+
+var errResourceUnsupported = errors.New("resource usage is not supported on this platform")
+
+// 资源采集
+type resourceGuard struct {
+	mu      sync.RWMutex
+	usage   ResourceUsage
+	lastCPU float64   //上次采集的累计CPU时间,秒
+	lastAt  time.Time //上次采集时间
+}
+
+// 是否开启资源保护
+func (e *executor) guardEnabled() bool {
+	return e.opts.MaxMemoryPercent > 0 || e.opts.MaxCPUPercent > 0
+}
+
+// 启动资源采集,执行器停止时退出
+func (e *executor) startResourceGuard() {
+	if !e.guardEnabled() {
+		return
+	}
+	if err := e.guard.sample(); err != nil {
+		e.log.Error("资源采集失败,资源保护不生效", F(FieldError, err))
+		return
+	}
+	interval := e.opts.ResourceInterval
+	if interval <= 0 {
+		interval = DefaultResourceInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-e.done:
+				return
+			case <-ticker.C:
+				if err := e.guard.sample(); err != nil {
+					e.log.Warn("资源采集失败", F(FieldError, err))
+				}
+			}
+		}
+	}()
+}
+
+// 采集一次资源使用情况,CPU使用率为两次采集之间的平均值
+func (g *resourceGuard) sample() error {
+	usage, limit, err := readMemory()
+	if err != nil {
+		return err
+	}
+	cpu, err := readCPU()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	cores := cpuCores()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	u := ResourceUsage{MemoryUsage: usage, MemoryLimit: limit, CPUCores: cores, UpdatedAt: now}
+	if limit > 0 {
+		u.MemoryPercent = float64(usage) * 100 / float64(limit)
+	}
+	if !g.lastAt.IsZero() && cores > 0 {
+		if elapsed := now.Sub(g.lastAt).Seconds(); elapsed > 0 {
+			u.CPUPercent = (cpu - g.lastCPU) / elapsed / cores * 100
+		}
+	}
+	g.usage, g.lastCPU, g.lastAt = u, cpu, now
+	return nil
+}
+
+// ResourceUsage 最近一次采集的资源使用情况,未开启资源保护时为零值
+func (e *executor) ResourceUsage() ResourceUsage {
+	e.guard.mu.RLock()
+	defer e.guard.mu.RUnlock()
+	return e.guard.usage
+}
+
+// 资源超过阈值时返回原因
+func (e *executor) overloaded() (string, bool) {
+	if !e.guardEnabled() {
+		return "", false
+	}
+	u := e.ResourceUsage()
+	if u.UpdatedAt.IsZero() {
+		return "", false
+	}
+	if e.opts.MaxMemoryPercent > 0 && u.MemoryPercent > e.opts.MaxMemoryPercent {
+		return fmt.Sprintf("memory usage %.1f%% exceeds %.1f%%", u.MemoryPercent, e.opts.MaxMemoryPercent), true
+	}
+	if e.opts.MaxCPUPercent > 0 && u.CPUPercent > e.opts.MaxCPUPercent {
+		return fmt.Sprintf("cpu usage %.1f%% exceeds %.1f%%", u.CPUPercent, e.opts.MaxCPUPercent), true
+	}
+	return "", false
+}
@@ -0,0 +1,235 @@
+package xxl
+
+import (
+	"bufio"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// cgroup和/proc的挂载路径,测试时替换为testdata下的目录
+var (
+	cgroupRoot = "/sys/fs/cgroup"
+	procRoot   = "/proc"
+)
+
+// cgroup v1 未限制内存时的上限值远大于实际内存
+const cgroupUnlimited = 1 << 62
+
+// 当前进程的cgroup目录,controller为空时返回cgroup v2目录,不存在时返回空
+func cgroupDir(controller string) string {
+	f, err := os.Open(filepath.Join(procRoot, "self", "cgroup"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		var base string
+		if controller == "" {
+			if parts[0] != "0" || parts[1] != "" {
+				continue
+			}
+			if _, err = os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+				return ""
+			}
+			base = cgroupRoot
+		} else {
+			found := false
+			for _, c := range strings.Split(parts[1], ",") {
+				if c == controller {
+					found = true
+				}
+			}
+			if !found {
+				continue
+			}
+			base = filepath.Join(cgroupRoot, controller)
+		}
+		//容器内cgroup命名空间下路径可能不存在,使用根目录
+		if dir := filepath.Join(base, parts[2]); exists(dir) {
+			return dir
+		}
+		if exists(base) {
+			return base
+		}
+		return ""
+	}
+	return ""
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// 读取文件中的整数
+func readUint(path string) (uint64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// 读取memory.stat中 "key value" 格式的值
+func memoryStat(dir, key string) (uint64, bool) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "memory.stat"))
+	if err != nil {
+		return 0, false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == key {
+			n, err := strconv.ParseUint(fields[1], 10, 64)
+			return n, err == nil
+		}
+	}
+	return 0, false
+}
+
+// 工作集内存,cgroup的使用量包含页缓存,减去可回收的非活跃文件页,与kubelet的计算方式一致
+func workingSet(dir string, usage uint64, inactiveKey string) uint64 {
+	if inactive, ok := memoryStat(dir, inactiveKey); ok {
+		if inactive < usage {
+			return usage - inactive
+		}
+		return 0
+	}
+	return usage
+}
+
+// 内存使用量和上限,优先读取cgroup v2、v1,其次为进程RSS;cgroup未限制时上限为主机内存
+func readMemory() (usage, limit uint64, err error) {
+	if dir := cgroupDir(""); dir != "" {
+		if usage, err = readUint(filepath.Join(dir, "memory.current")); err == nil {
+			limit, _ = readUint(filepath.Join(dir, "memory.max")) //"max"表示未限制,解析失败为0
+			return workingSet(dir, usage, "inactive_file"), hostLimit(limit), nil
+		}
+	}
+	if dir := cgroupDir("memory"); dir != "" {
+		if usage, err = readUint(filepath.Join(dir, "memory.usage_in_bytes")); err == nil {
+			limit, _ = readUint(filepath.Join(dir, "memory.limit_in_bytes"))
+			return workingSet(dir, usage, "total_inactive_file"), hostLimit(limit), nil
+		}
+	}
+	if usage, err = procStatusKB(filepath.Join(procRoot, "self", "status"), "VmRSS"); err != nil {
+		return 0, 0, err
+	}
+	return usage, hostLimit(0), nil
+}
+
+// cgroup未限制内存时使用主机内存
+func hostLimit(limit uint64) uint64 {
+	if limit > 0 && limit < cgroupUnlimited {
+		if total, err := procStatusKB(filepath.Join(procRoot, "meminfo"), "MemTotal"); err == nil && total > 0 && total < limit {
+			return total
+		}
+		return limit
+	}
+	total, _ := procStatusKB(filepath.Join(procRoot, "meminfo"), "MemTotal")
+	return total
+}
+
+// 读取 /proc 下 "Key:   123 kB" 格式的值,返回字节数
+func procStatusKB(path, key string) (uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, key+":") {
+			continue
+		}
+		fields := strings.Fields(line[len(key)+1:])
+		if len(fields) == 0 {
+			break
+		}
+		n, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return n * 1024, nil
+	}
+	return 0, errors.New(key + " not found in " + path)
+}
+
+// 累计CPU时间(秒),优先读取cgroup v2 cpu.stat、v1 cpuacct.usage,其次为 /proc/self/stat
+func readCPU() (float64, error) {
+	if dir := cgroupDir(""); dir != "" {
+		if data, err := ioutil.ReadFile(filepath.Join(dir, "cpu.stat")); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				fields := strings.Fields(line)
+				if len(fields) == 2 && fields[0] == "usage_usec" {
+					usec, err := strconv.ParseFloat(fields[1], 64)
+					if err != nil {
+						return 0, err
+					}
+					return usec / 1e6, nil
+				}
+			}
+		}
+	}
+	if dir := cgroupDir("cpuacct"); dir != "" {
+		if ns, err := readUint(filepath.Join(dir, "cpuacct.usage")); err == nil {
+			return float64(ns) / 1e9, nil
+		}
+	}
+	data, err := ioutil.ReadFile(filepath.Join(procRoot, "self", "stat"))
+	if err != nil {
+		return 0, err
+	}
+	//进程名可能包含空格,从最后一个")"之后解析,utime、stime为第14、15个字段
+	s := string(data)
+	fields := strings.Fields(s[strings.LastIndex(s, ")")+1:])
+	if len(fields) < 13 {
+		return 0, errors.New("invalid /proc/self/stat")
+	}
+	utime, err := strconv.ParseFloat(fields[11], 64)
+	if err != nil {
+		return 0, err
+	}
+	stime, err := strconv.ParseFloat(fields[12], 64)
+	if err != nil {
+		return 0, err
+	}
+	return (utime + stime) / 100, nil //USER_HZ一般为100
+}
+
+// 可用核数,优先读取cgroup CPU配额
+func cpuCores() float64 {
+	if dir := cgroupDir(""); dir != "" {
+		if data, err := ioutil.ReadFile(filepath.Join(dir, "cpu.max")); err == nil {
+			fields := strings.Fields(string(data)) // "quota period" 或 "max period"
+			if len(fields) == 2 && fields[0] != "max" {
+				quota, err1 := strconv.ParseFloat(fields[0], 64)
+				period, err2 := strconv.ParseFloat(fields[1], 64)
+				if err1 == nil && err2 == nil && quota > 0 && period > 0 {
+					return quota / period
+				}
+			}
+		}
+	}
+	if dir := cgroupDir("cpu"); dir != "" {
+		quota, err1 := ioutil.ReadFile(filepath.Join(dir, "cpu.cfs_quota_us"))
+		period, err2 := readUint(filepath.Join(dir, "cpu.cfs_period_us"))
+		if err1 == nil && err2 == nil && period > 0 {
+			if q, err := strconv.ParseFloat(strings.TrimSpace(string(quota)), 64); err == nil && q > 0 {
+				return q / float64(period)
+			}
+		}
+	}
+	return float64(runtime.NumCPU())
+}
@@ -0,0 +1,39 @@
+package xxl
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReadMemoryCgroup(t *testing.T) {
+	const memTotal = 16384000 * 1024
+	cases := []struct {
+		name  string
+		dir   string //testdata/cgroup下的目录
+		usage uint64
+		limit uint64
+	}{
+		//memory.current 1GiB 减去 inactive_file 256MiB
+		{"v2", "v2", 1<<30 - 256<<20, 2 << 30},
+		//usage_in_bytes 1GiB 减去 total_inactive_file 256MiB,未限制时为主机内存
+		{"v1", "v1", 1<<30 - 256<<20, memTotal},
+		//unified挂载在子目录,读取v1 memory控制器
+		{"hybrid", "hybrid", 512<<20 - 128<<20, 1 << 30},
+	}
+	oldCgroup, oldProc := cgroupRoot, procRoot
+	defer func() { cgroupRoot, procRoot = oldCgroup, oldProc }()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			root := filepath.Join("testdata", "cgroup", c.dir)
+			cgroupRoot = filepath.Join(root, "sys", "fs", "cgroup")
+			procRoot = filepath.Join(root, "proc")
+			usage, limit, err := readMemory()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if usage != c.usage || limit != c.limit {
+				t.Fatalf("readMemory() = %d, %d, want %d, %d", usage, limit, c.usage, c.limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+// +build !linux
+
+package xxl
+
+import "runtime"
+
+func readMemory() (usage, limit uint64, err error) {
+	return 0, 0, errResourceUnsupported
+}
+
+func readCPU() (float64, error) {
+	return 0, errResourceUnsupported
+}
+
+func cpuCores() float64 {
+	return float64(runtime.NumCPU())
+}
@@ -0,0 +1,182 @@
+package xxl
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RunInfo 运行中的任务信息
+type RunInfo struct {
+	JobID      int64         `json:"jobId"`      //任务ID
+	LogID      int64         `json:"logId"`      //调度日志ID
+	Handler    string        `json:"handler"`    //任务标识
+	Params     string        `json:"params"`     //任务参数
+	ShardIndex int64         `json:"shardIndex"` //分片参数：当前分片
+	ShardTotal int64         `json:"shardTotal"` //分片参数：总分片
+	StartTime  time.Time     `json:"startTime"`  //开始时间,排队或限流等待中为零值
+	Elapsed    time.Duration `json:"elapsed"`    //已运行时长
+	Zombie     bool          `json:"zombie"`     //已终止但任务函数仍未返回
+	Progress   *Progress     `json:"progress"`   //最近一次上报的进度
+	LimitDelay time.Duration `json:"limitDelay"` //限流等待时间
+	Limiting   bool          `json:"limiting"`   //正在等待限流
+	Queued     bool          `json:"queued"`     //并发已满排队等待
+	Priority   int           `json:"priority"`   //优先级
+}
+
+// RunRecord 已完成的任务记录
+type RunRecord struct {
+	JobID      int64         `json:"jobId"`      //任务ID
+	LogID      int64         `json:"logId"`      //调度日志ID
+	Handler    string        `json:"handler"`    //任务标识
+	Params     string        `json:"params"`     //任务参数
+	ShardIndex int64         `json:"shardIndex"` //分片参数：当前分片
+	ShardTotal int64         `json:"shardTotal"` //分片参数：总分片
+	StartTime  time.Time     `json:"startTime"`  //开始时间,未开始即被终止时为零值
+	EndTime    time.Time     `json:"endTime"`    //结束时间
+	Duration   time.Duration `json:"duration"`   //执行时长
+	Code       int64         `json:"code"`       //结果码 200 表示成功
+	Msg        string        `json:"msg"`        //结果信息
+	Progress   *Progress     `json:"progress"`   //最终进度
+}
+
+// 最近完成记录,环形缓冲
+type history struct {
+	mu      sync.Mutex
+	records []RunRecord
+	next    int
+	full    bool
+}
+
+func newHistory(size int) *history {
+	if size < 0 {
+		size = 0
+	}
+	return &history{records: make([]RunRecord, size)}
+}
+
+func (h *history) add(r RunRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.records) == 0 {
+		return
+	}
+	h.records[h.next] = r
+	h.next = (h.next + 1) % len(h.records)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// 按完成时间倒序
+func (h *history) list() []RunRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := h.next
+	if h.full {
+		n = len(h.records)
+	}
+	res := make([]RunRecord, 0, n)
+	for i := 1; i <= n; i++ {
+		res = append(res, h.records[(h.next-i+len(h.records))%len(h.records)])
+	}
+	return res
+}
+
+// Running 运行中的任务,包含已终止但未退出的任务,按开始时间排序,未开始的排在最后
+func (e *executor) Running() []RunInfo {
+	var infos []RunInfo
+	for _, t := range e.runList.GetAll() {
+		infos = append(infos, t.runInfo())
+	}
+	for _, t := range e.zombieList.GetAll() {
+		infos = append(infos, t.runInfo())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		a, b := infos[i].StartTime, infos[j].StartTime
+		if a.IsZero() != b.IsZero() {
+			return b.IsZero()
+		}
+		return a.Before(b)
+	})
+	return infos
+}
+
+// History 最近完成的任务,按完成时间倒序
+func (e *executor) History() []RunRecord {
+	return e.history.list()
+}
+
+// 记录完成的任务
+func (e *executor) record(t *Task, code int64, msg string) {
+	start := t.startTime()
+	end := time.Unix(0, t.EndTime*int64(time.Millisecond))
+	e.history.add(RunRecord{
+		JobID:      t.Id,
+		LogID:      t.Param.LogID,
+		Handler:    t.Name,
+		Params:     t.Param.ExecutorParams,
+		ShardIndex: t.Param.BroadcastIndex,
+		ShardTotal: t.Param.BroadcastTotal,
+		StartTime:  start,
+		EndTime:    end,
+		Duration:   t.duration(),
+		Code:       code,
+		Msg:        msg,
+		Progress:   t.lastProgress(),
+	})
+}
+
+// 调试接口,返回运行中任务、最近完成记录、指标和注册状态
+func (e *executor) debugRuns(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		writeError(writer, newRequestError(http.StatusMethodNotAllowed, "method %s not allowed", request.Method))
+		return
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"running":  e.Running(),
+		"history":  e.History(),
+		"metrics":  e.Metrics(),
+		"registry": e.RegistryStatus(),
+		"resource": e.ResourceUsage(),
+	})
+	writer.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	_, _ = writer.Write(data)
+}
+
+// Zombies 已终止但任务函数仍未返回的任务
+func (e *executor) Zombies() []RunInfo {
+	var infos []RunInfo
+	for _, t := range e.zombieList.GetAll() {
+		infos = append(infos, t.runInfo())
+	}
+	return infos
+}
+
+// 运行信息
+func (t *Task) runInfo() RunInfo {
+	start := t.startTime()
+	var elapsed time.Duration
+	if !start.IsZero() {
+		elapsed = time.Since(start)
+	}
+	return RunInfo{
+		JobID:      t.Id,
+		LogID:      t.Param.LogID,
+		Handler:    t.Name,
+		Params:     t.Param.ExecutorParams,
+		ShardIndex: t.Param.BroadcastIndex,
+		ShardTotal: t.Param.BroadcastTotal,
+		StartTime:  start,
+		Elapsed:    elapsed,
+		Zombie:     t.isZombie(),
+		Progress:   t.lastProgress(),
+		LimitDelay: t.limitDelay,
+		Limiting:   atomic.LoadInt32(&t.limiting) == 1,
+		Queued:     atomic.LoadInt32(&t.queued) == 1,
+		Priority:   t.opts.priority,
+	}
+}
@@ -0,0 +1,132 @@
+package xxl
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/**
+本地调度:按cron表达式在执行器内触发已注册的任务,与调度中心调度走相同流程(阻塞策略、超时、中间件、日志),
+结果只记录在本地,不回调调度中心。本地调度的LogID为负数,JobID为0时使用负的调度ID
+*/
+
+// ScheduleInfo 本地调度信息
+type ScheduleInfo struct {
+	ID      int64     `json:"id"`      //调度ID
+	Spec    string    `json:"spec"`    //cron表达式
+	Handler string    `json:"handler"` //任务标识
+	JobID   int64     `json:"jobId"`   //任务ID
+	Next    time.Time `json:"next"`    //下次调度时间
+}
+
+// 本地调度列表
+type scheduler struct {
+	mu     sync.Mutex
+	seq    int64              //调度ID
+	logSeq int64              //本地调度日志ID,递减
+	jobs   map[int64]*cronJob //调度列表
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{
+		logSeq: -time.Now().UnixNano() / int64(time.Millisecond),
+		jobs:   make(map[int64]*cronJob),
+	}
+}
+
+// 单个本地调度
+type cronJob struct {
+	next  int64 //下次调度时间,Unix毫秒,放在首位保证原子操作对齐
+	id    int64
+	spec  string
+	sched schedule
+	param RunReq
+	stop  chan struct{}
+}
+
+// Schedule 按cron表达式在本地调度任务,返回调度ID
+func (e *executor) Schedule(spec string, param RunReq) (int64, error) {
+	sched, err := parseCron(spec)
+	if err != nil {
+		return 0, err
+	}
+	if err = param.validateFields(); err != nil {
+		return 0, err
+	}
+	if !e.regList.Exists(param.ExecutorHandler) {
+		return 0, fmt.Errorf("task %s not registered", param.ExecutorHandler)
+	}
+	s := e.sched
+	s.mu.Lock()
+	s.seq++
+	j := &cronJob{id: s.seq, spec: spec, sched: sched, param: param, stop: make(chan struct{})}
+	if j.param.JobID == 0 {
+		j.param.JobID = -j.id
+	}
+	s.jobs[j.id] = j
+	s.mu.Unlock()
+	go e.cronLoop(j)
+	e.log.Info("本地调度添加", F("schedule_id", j.id), F("spec", spec), F(FieldHandler, param.ExecutorHandler))
+	return j.id, nil
+}
+
+// Unschedule 删除本地调度,不影响正在运行的任务
+func (e *executor) Unschedule(id int64) {
+	s := e.sched
+	s.mu.Lock()
+	j, ok := s.jobs[id]
+	delete(s.jobs, id)
+	s.mu.Unlock()
+	if ok {
+		close(j.stop)
+		e.log.Info("本地调度删除", F("schedule_id", id), F(FieldHandler, j.param.ExecutorHandler))
+	}
+}
+
+// Schedules 本地调度列表
+func (e *executor) Schedules() []ScheduleInfo {
+	s := e.sched
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]ScheduleInfo, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		info := ScheduleInfo{ID: j.id, Spec: j.spec, Handler: j.param.ExecutorHandler, JobID: j.param.JobID}
+		if next := atomic.LoadInt64(&j.next); next > 0 {
+			info.Next = time.Unix(0, next*int64(time.Millisecond))
+		}
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, k int) bool { return list[i].ID < list[k].ID })
+	return list
+}
+
+// 按调度时间循环触发,执行器停止或删除调度时退出
+func (e *executor) cronLoop(j *cronJob) {
+	for {
+		next := j.sched.Next(time.Now())
+		if next.IsZero() {
+			e.log.Warn("本地调度没有下次执行时间", F("schedule_id", j.id), F("spec", j.spec))
+			return
+		}
+		atomic.StoreInt64(&j.next, next.UnixNano()/int64(time.Millisecond))
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-j.stop:
+			timer.Stop()
+			return
+		case <-e.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		param := j.param
+		param.LogID = atomic.AddInt64(&e.sched.logSeq, -1)
+		param.LogDateTime = time.Now().UnixNano() / int64(time.Millisecond)
+		if msg, ok := e.trigger(&param, true); !ok {
+			e.log.Warn("本地调度被拒绝", append(runFields(&param), F("schedule_id", j.id), F("msg", msg))...)
+		}
+	}
+}
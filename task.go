@@ -3,7 +3,11 @@ package xxl
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // TaskFunc 任务执行函数
@@ -17,25 +21,213 @@ type Task struct {
 	Param     *RunReq
 	fn        TaskFunc
 	Cancel    context.CancelFunc
-	StartTime int64
+	StartTime int64 //开始执行时间,排队或限流等待中为0,超时从此时开始计算
 	EndTime   int64
 	//日志
-	log Logger
+	log *sysLogger
+
+	done   chan struct{}                 //任务函数返回后关闭
+	killed int32                         //是否被终止
+	failed int32                         //任务是否标记失败
+	params interface{}                   //BindParams解析的参数
+	local  bool                          //本地调度,不回调调度中心
+	opts   taskOptions                   //注册选项
+	runner func(t *Task) (int64, string) //自定义执行方式,如子进程隔离
+	emit   func(event Event)             //事件派发
+	jlog   *jobLogger                    //任务日志
+
+	mu       sync.Mutex
+	children []childJob      //执行成功后触发的子任务
+	onChild  func(t *Task)   //触发子任务,未设置ChildTriggerPath时为nil
+	progress *Progress       //最近一次上报的进度
+	ckpt     CheckpointStore //断点存储
+
+	limitDelay time.Duration //限流等待时间
+	limiting   int32         //正在等待限流
+	queued     int32         //在等待队列中
+	slot       bool          //占用执行池并发
+	//进度更新
+	onProgress func(t *Task, p Progress)
+	//panic处理,返回回调信息
+	onPanic func(t *Task, err interface{}, stack []byte) string
+}
+
+// TaskOption 任务注册选项
+type TaskOption func(o *taskOptions)
+
+type taskOptions struct {
+	isolated    bool         //子进程隔离运行
+	paramType   reflect.Type //参数绑定类型
+	paramFormat ParamFormat  //参数格式
+	limiter     *rateLimiter //限流
+	priority    int          //优先级
+	err         error        //选项错误,注册时检查
+}
+
+// Isolated 在子进程中运行任务,终止时可强制杀死进程;
+// 子进程为当前程序的副本,程序需调用Run,子进程在Run中执行任务后退出,中间件在子进程中生效
+func Isolated() TaskOption {
+	return func(o *taskOptions) {
+		o.isolated = true
+	}
 }
 
 // Run 运行任务
 func (t *Task) Run(callback func(code int64, msg string)) {
-	defer func(cancel func()) {
+	t.jlog.Write("----------- 任务开始执行 handler:%s params:%s -----------", t.Name, t.Param.ExecutorParams)
+	var code int64 = FailureCode
+	msg := "task canceled before start"
+	if t.Ext.Err() == nil {
+		code, msg = t.call()
+	}
+	t.finish()
+	if t.isKilled() {
+		code, msg = FailureCode, "task killed"
+	}
+	msg = progressMsg(msg, t.lastProgress())
+	t.jlog.Write("----------- 任务执行结束 code:%d msg:%s -----------", code, msg)
+	t.safe("任务回调panic", func() { callback(code, msg) })
+	//回调后再触发子任务,不延迟本次调度的回调
+	if code == SuccessCode && t.onChild != nil {
+		t.safe("子任务触发panic", func() { t.onChild(t) })
+	}
+}
+
+// 标记开始执行,设置了超时时从此时开始计时,返回释放超时计时器的函数
+func (t *Task) start(timeout time.Duration) context.CancelFunc {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.StartTime = time.Now().UnixNano() / int64(time.Millisecond)
+	if timeout <= 0 {
+		return func() {}
+	}
+	ctx, cancel := context.WithTimeout(t.Ext, timeout)
+	t.Ext = ctx
+	return cancel
+}
+
+// 任务上下文,开始执行时会替换为带超时的上下文,其他goroutine需通过该方法读取
+func (t *Task) context() context.Context {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.Ext
+}
+
+// 开始执行时间,未开始时为零值
+func (t *Task) startTime() time.Time {
+	t.mu.Lock()
+	ms := t.StartTime
+	t.mu.Unlock()
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+// 执行时长,未开始即结束时为0
+func (t *Task) duration() time.Duration {
+	start := t.startTime()
+	if start.IsZero() {
+		return 0
+	}
+	return time.Unix(0, t.EndTime*int64(time.Millisecond)).Sub(start)
+}
+
+// 执行fn,panic时记录日志
+func (t *Task) safe(msg string, fn func()) {
+	defer func() {
 		if err := recover(); err != nil {
-			t.log.Info(t.Info()+" panic: %v", err)
-			debug.PrintStack() //堆栈跟踪
-			callback(FailureCode, fmt.Sprintf("task panic:%v", err))
-			cancel()
+			t.log.Error(msg, F("panic", err), F("stack", string(debug.Stack())))
+		}
+	}()
+	fn()
+}
+
+// 执行任务函数
+func (t *Task) call() (code int64, msg string) {
+	defer func() {
+		if err := recover(); err != nil {
+			stack := debug.Stack() //堆栈跟踪
+			code, msg = FailureCode, fmt.Sprintf("task panic:%v", err)
+			if t.onPanic != nil {
+				msg = t.onPanic(t, err, stack)
+				return
+			}
+			t.log.Error("任务panic", F("panic", err), F("stack", string(stack)))
+		}
+	}()
+	if t.opts.paramType != nil {
+		params, err := bindParams(t.opts.paramType, t.opts.paramFormat, t.Param.ExecutorParams)
+		if err != nil {
+			msg = "invalid params: " + err.Error()
+			t.log.Warn("任务参数错误", F(FieldError, err))
+			t.jlog.Write("%s", msg)
+			return FailureCode, msg
+		}
+		t.params = params
+	}
+	if t.runner != nil {
+		return t.runner(t)
+	}
+	msg = t.fn(t.Ext, t.Param)
+	if atomic.LoadInt32(&t.failed) == 1 {
+		return FailureCode, msg
+	}
+	return SuccessCode, msg
+}
+
+// Fail 标记本次调度执行失败,返回的信息作为回调信息,用法: return xxl.Fail(ctx, "xxx")
+func Fail(ctx context.Context, format string, a ...interface{}) string {
+	if t := taskFrom(ctx); t != nil {
+		atomic.StoreInt32(&t.failed, 1)
+	}
+	return fmt.Sprintf(format, a...)
+}
+
+// 任务函数已返回
+func (t *Task) finish() {
+	t.Cancel()
+	if t.done != nil {
+		close(t.done)
+	}
+}
+
+// 终止任务
+func (t *Task) kill() {
+	atomic.StoreInt32(&t.killed, 1)
+	t.Cancel()
+}
+
+func (t *Task) isKilled() bool {
+	return atomic.LoadInt32(&t.killed) == 1
+}
+
+// 已取消(终止、超时)但任务函数仍未返回
+func (t *Task) isZombie() bool {
+	return t.context().Err() != nil && !t.wait(0)
+}
+
+// 等待任务函数返回,返回是否已停止
+func (t *Task) wait(timeout time.Duration) bool {
+	if t.done == nil {
+		return true
+	}
+	if timeout <= 0 {
+		select {
+		case <-t.done:
+			return true
+		default:
+			return false
 		}
-	}(t.Cancel)
-	msg := t.fn(t.Ext, t.Param)
-	callback(SuccessCode, msg)
-	return
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-t.done:
+		return true
+	case <-timer.C:
+		return false
+	}
 }
 
 // Info 任务信息
@@ -1,6 +1,9 @@
 package xxl
 
-import "sync"
+import (
+	"sort"
+	"sync"
+)
 
 //任务列表 [JobID]执行函数,并行执行时[+LogID]
 type taskList struct {
@@ -22,11 +25,40 @@ func (t *taskList) Get(key string) *Task {
 	return t.data[key]
 }
 
-// GetAll 获取数据
+// GetAll 获取数据副本
 func (t *taskList) GetAll() map[string]*Task {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
-	return t.data
+	data := make(map[string]*Task, len(t.data))
+	for k, v := range t.data {
+		data[k] = v
+	}
+	return data
+}
+
+// Keys 获取全部key,已排序
+func (t *taskList) Keys() []string {
+	t.mu.RLock()
+	keys := make([]string, 0, len(t.data))
+	for k := range t.data {
+		keys = append(keys, k)
+	}
+	t.mu.RUnlock()
+	sort.Strings(keys)
+	return keys
+}
+
+// Find 查找匹配的数据
+func (t *taskList) Find(match func(*Task) bool) []*Task {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var res []*Task
+	for _, v := range t.data {
+		if match(v) {
+			res = append(res, v)
+		}
+	}
+	return res
 }
 
 // Del 设置数据
@@ -38,6 +70,8 @@ func (t *taskList) Del(key string) {
 
 // Len 长度
 func (t *taskList) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	return len(t.data)
 }
 
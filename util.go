@@ -56,6 +56,16 @@ func returnIdleBeat(code int64) []byte {
 	return str
 }
 
+//失败返回
+func returnFailure(msg string) []byte {
+	data := &res{
+		Code: FailureCode,
+		Msg:  msg,
+	}
+	str, _ := json.Marshal(data)
+	return str
+}
+
 //通用返回
 func returnGeneral() []byte {
 	data := &res{